@@ -2,23 +2,57 @@ package boot
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
+	"sync"
 
 	"github.com/liuyuanxiang/go-hulc/config"
 	"github.com/liuyuanxiang/go-hulc/logger"
+	"github.com/liuyuanxiang/go-hulc/util"
 	"github.com/gin-gonic/gin"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 )
 
+// DefaultConfigFile 应用默认加载的配置文件
+const DefaultConfigFile = "app.yaml"
+
 // Application 所有类型应用都需要具备的基础信息
 type Application struct {
+	// timeoutCount、rateLimitCount 及 rateLimitLogged 通过 atomic 读写，放在开头以保证 32 位平台上的 64 位对齐
+	timeoutCount    uint64
+	rateLimitCount  uint64
+	rateLimitLogged int64
+
 	Name    string
 	Type    int32
+	Env     string
 	LogPath string
 
-	Config *config.Config
-	Log    logger.LogInterface
+	// ConfigFile 应用加载的配置文件名，默认为 app.yaml
+	ConfigFile string
+	// ConfigFiles 在 ConfigFile 之后加载的其他配置文件，如 mongo.yaml、clients.yaml
+	ConfigFiles []string
+	// configLoaders 在加载配置文件之前执行，用于加载 WithConfigFS 等设置的内嵌配置
+	configLoaders []func() error
+
+	Config    *config.Config
+	Log       logger.LogInterface
+	Scheduler *Scheduler
+
+	timeouts *timeoutPolicy
+
+	limitMu sync.RWMutex
+	limiter *rateLimiter
+
+	addrMu sync.RWMutex
+	addrs  map[string]net.Addr
+
+	stopMu    sync.Mutex
+	stopHooks []func()
+
+	flags appFlags
 }
 
 // GRPCApplication 基于 gRPC 实现的 RPC 服务应用类型
@@ -27,14 +61,25 @@ type Application struct {
 type GRPCApplication struct {
 	Application
 	GRPCServer      *grpc.Server
+	defaultServer   *grpc.Server
 	GatewayServeMux *runtime.ServeMux
 	HTTPServer      *http.Server
 
 	isOpenGateway bool
 	// isSharePort   bool
 
+	serverOpts   []grpc.ServerOption
+	streamRoutes []string
+
+	// RegisterGRPCServer 用于注册 gRPC 服务实现
+	// 配置了 grpc.server 时 GRPCServer 会在配置加载完成后重新创建，因此推荐通过该函数注册服务
 	RegisterGRPCServer func(*grpc.Server)
 	RegisterGateway    func(context.Context, *runtime.ServeMux) error
+
+	// GinEngin 挂载在 HTTP 接口服务 GinPrefixes 路径前缀下的 Gin Engine，通过 WithGin 开启
+	GinEngin      *gin.Engine
+	GinPrefixes   []string
+	RegisterRoute func(*gin.Engine) error
 }
 
 // GinApplication 基于 Gin 实现的 HTTP 服务应用类型
@@ -42,21 +87,223 @@ type GRPCApplication struct {
 // 无需处理繁琐的 protobuf 定义及相关 IDL 文件资源的维护
 type GinApplication struct {
 	Application
-	GinEngin *gin.Engine
+	GinEngin   *gin.Engine
+	HTTPServer *http.Server
 
 	RegisterRoute func(*gin.Engine) error
 }
 
-type GRPCAppOption func(*GRPCApplication)
-type GinAppOption func(*GinApplication)
-
 // Init 执行一些应用的初始化动作
-// 1. 根据设置好的内容加载对应的配置文件内容
+// 1. 依次加载内嵌配置、ConfigFile 及 ConfigFiles，并合并对应的环境配置及本地配置文件，加载失败时直接返回错误
+// 2. 校验应用及各组件通过 Config.Require 声明的配置，所有缺失或无效的配置项汇总在一个错误中返回
+// 3. 根据 logger.level 设置日志处理器的最低级别，配置变更时同步修改
+// 4. 通过应用的日志处理器记录之后的每一次配置变更
+// 5. 根据配置生成请求的超时策略
+// 6. 开启 config.watch 时监听配置文件的变更
 func (app *Application) Init() error {
 	// 加载对应的配置文件内容
-	app.Config.Load("app.yaml")
+	file := app.ConfigFile
+	if file == "" {
+		file = DefaultConfigFile
+	}
+	app.ensureConfig().SetEnv(app.Env)
+	for _, load := range app.configLoaders {
+		if err := load(); err != nil {
+			return err
+		}
+	}
+	if err := app.Config.LoadFiles(append([]string{file}, app.ConfigFiles...)...); err != nil {
+		return err
+	}
+
+	app.Config.Require("timeout", &timeoutConfig{})
+	app.Config.Require("config", &watchConfig{})
+	app.Config.Require("logger", &loggerConfig{})
+	app.Config.Require("ratelimit", &rateLimitConfig{})
+	app.Config.AddValidator(validateFeatures)
+	app.Config.AddValidator(validateLogLevel)
+	if err := app.Config.Validate(); err != nil {
+		return err
+	}
+	if err := validateFeatures(app.Config); err != nil {
+		return err
+	}
+	if err := validateLogLevel(app.Config); err != nil {
+		return err
+	}
+	app.setupLogLevel()
+	app.logConfigChanges()
+
+	timeouts, err := loadTimeoutPolicy(app.Config)
+	if err != nil {
+		return err
+	}
+	app.timeouts = timeouts
+	if err := app.setupRateLimit(); err != nil {
+		return err
+	}
+	return app.watchConfig()
+}
+
+// logConfigChanges 通过应用的日志处理器记录配置的每一项变更，敏感配置项的取值会被隐藏
+func (app *Application) logConfigChanges() {
+	app.Config.OnReload(func(prev, next *config.Snapshot, changes []config.Change) {
+		for _, ch := range changes {
+			app.Log.Info(fmt.Sprintf("配置变更 v%d -> v%d %s", prev.Version(), next.Version(), ch))
+		}
+	})
+}
+
+// loggerConfig 日志处理器的配置，level 的取值由 validateLogLevel 按 logger.ParseLevel 校验
+type loggerConfig struct {
+	Level string `mapstructure:"level"`
+}
+
+// validateLogLevel 校验 logger.level 可以被 logger.ParseLevel 解析，与 SetLogLevel 使用相同的规则
+func validateLogLevel(c *config.Config) error {
+	if !c.IsSet("logger.level") {
+		return nil
+	}
+	if _, err := logger.ParseLevel(c.GetString("logger.level")); err != nil {
+		return fmt.Errorf("logger.level 配置错误: %v", err)
+	}
 	return nil
 }
 
+// setupLogLevel 根据 logger.level 设置日志处理器的最低级别，并在配置变更时同步修改，删除配置时恢复为 info
+// 自定义的日志处理器未实现 logger.Leveler 时只记录一条警告
+func (app *Application) setupLogLevel() {
+	apply := func() {
+		if err := app.SetLogLevel(app.Config.GetString("logger.level")); err != nil {
+			app.Log.Warn("日志级别设置失败 err:", err)
+		}
+	}
+	if app.Config.IsSet("logger.level") {
+		apply()
+	}
+	app.Config.OnChange("logger.level", func(_, _ interface{}) { apply() })
+}
+
+// SetLogLevel 在运行时修改应用日志处理器记录日志的最低级别，如 debug、info、warn、error
+// 日志处理器需要实现 logger.Leveler，默认的日志处理器及 logger.ILog 均已实现
+func (app *Application) SetLogLevel(level string) error {
+	l, err := logger.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	lv, ok := app.Log.(logger.Leveler)
+	if !ok {
+		return fmt.Errorf("日志处理器 %T 不支持设置日志级别", app.Log)
+	}
+	lv.SetLevel(l)
+	return nil
+}
+
+// watchConfig 配置文件的监听配置
+type watchConfig struct {
+	Watch bool `mapstructure:"watch"`
+}
+
+// listenConfig 服务的监听配置，address 与 port 至少需要配置一个
+// port 为 0 时由系统分配端口，因此使用指针区分未配置与配置为 0
+type listenConfig struct {
+	Address string `mapstructure:"address"`
+	Port    *int   `mapstructure:"port" validate:"required_without=Address,omitempty,min=0,max=65535"`
+}
+
+// watchConfig 配置了 config.watch 为 true 时监听配置文件的变更并自动重新加载，应用退出时停止监听
+func (app *Application) watchConfig() error {
+	var c watchConfig
+	if err := app.Config.Bind("config", &c); err != nil || !c.Watch {
+		return err
+	}
+
+	err := app.Config.Watch(func(err error) {
+		app.Log.Error("配置文件重新加载失败 err:", err)
+	})
+	if err != nil {
+		return fmt.Errorf("配置文件监听失败 err: %v", err)
+	}
+	app.OnStop(app.Config.StopWatch)
+	return nil
+}
+
+// AddJob 向应用的定时任务调度器注册一个任务，任务会在应用启动后开始调度，并随应用退出而停止
+func (app *Application) AddJob(name string, fn JobFunc, opts ...JobOption) error {
+	if app.Scheduler == nil {
+		app.Scheduler = NewScheduler()
+	}
+	return app.Scheduler.AddJob(name, fn, opts...)
+}
+
 // SetLogger 将应用的日志处理器设置为一个 LogInterface 接口的自定义实现
 func (app *Application) SetLogger(lg logger.LogInterface) { app.Log = lg }
+
+// OnStop 注册一个在应用优雅退出时执行的函数，通常用于释放应用持有的数据库连接等资源
+// 函数按注册的相反顺序执行，每个函数只会执行一次
+func (app *Application) OnStop(fn func()) {
+	app.stopMu.Lock()
+	defer app.stopMu.Unlock()
+	app.stopHooks = append(app.stopHooks, fn)
+}
+
+// runStopHooks 按注册的相反顺序执行 OnStop 注册的函数
+func (app *Application) runStopHooks() {
+	app.stopMu.Lock()
+	hooks := app.stopHooks
+	app.stopHooks = nil
+	app.stopMu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
+
+// startScheduler 启动应用的定时任务调度器
+func (app *Application) startScheduler() error {
+	if app.Scheduler == nil {
+		return nil
+	}
+	return app.Scheduler.Start(app.Config, app.Log)
+}
+
+// stopScheduler 停止应用的定时任务调度器，并等待执行中的任务结束
+func (app *Application) stopScheduler() {
+	if app.Scheduler != nil {
+		app.Scheduler.Stop()
+	}
+}
+
+// HTTPAddr 返回 HTTP 接口服务实际监听的地址，服务启动前或未开启 HTTP 服务时返回 nil
+func (app *Application) HTTPAddr() net.Addr { return app.boundAddr("http") }
+
+// listen 根据 <prefix>.address 配置创建对应服务的 Listener，并记录实际监听的地址
+// 未设置 address 时兼容原有的 <prefix>.port 配置，监听所有网卡上的该端口
+func (app *Application) listen(prefix string) (net.Listener, error) {
+	addr := app.Config.GetString(prefix + ".address")
+	if addr == "" {
+		if !app.Config.IsSet(prefix + ".port") {
+			return nil, fmt.Errorf("监听地址异常: %s.address 与 %s.port 均未配置", prefix, prefix)
+		}
+		addr = util.GetPortString(app.Config.GetInt64(prefix + ".port"))
+	}
+
+	lis, err := util.Listen(addr)
+	if err != nil {
+		return nil, fmt.Errorf("Listen %s err: %v", addr, err)
+	}
+
+	app.addrMu.Lock()
+	defer app.addrMu.Unlock()
+	if app.addrs == nil {
+		app.addrs = make(map[string]net.Addr)
+	}
+	app.addrs[prefix] = lis.Addr()
+	return lis, nil
+}
+
+func (app *Application) boundAddr(prefix string) net.Addr {
+	app.addrMu.RLock()
+	defer app.addrMu.RUnlock()
+	return app.addrs[prefix]
+}
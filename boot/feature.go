@@ -0,0 +1,52 @@
+package boot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/liuyuanxiang/go-hulc/config"
+)
+
+// featuresKey 功能开关的配置项
+//
+//	features:
+//	  new_checkout: true
+//	  beta_search: false
+//
+// 功能开关每次都读取当前的配置，配置重新加载后立即生效
+const featuresKey = "features"
+
+// Feature 返回功能开关是否开启，未配置的功能开关视为关闭
+func (app *Application) Feature(name string) bool {
+	return app.Config.GetBool(featuresKey + "." + name)
+}
+
+// OnFeatureChange 注册功能开关变更时的回调函数，enabled 为变更后的状态
+func (app *Application) OnFeatureChange(name string, fn func(enabled bool)) {
+	app.Config.OnChange(featuresKey+"."+name, func(_, _ interface{}) {
+		fn(app.Feature(name))
+	})
+}
+
+// validateFeatures 校验 features 下的每一项都是布尔值，避免拼写错误的取值被静默视为关闭
+func validateFeatures(c *config.Config) error {
+	if !c.IsSet(featuresKey) {
+		return nil
+	}
+	features, err := c.GetStringMapE(featuresKey)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for name := range features {
+		if _, err := c.GetBoolE(featuresKey + "." + name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("features 配置格式错误: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
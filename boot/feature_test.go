@@ -0,0 +1,42 @@
+package boot
+
+import (
+	"testing"
+
+	"github.com/liuyuanxiang/go-hulc/config"
+)
+
+func TestFeatureFollowsConfigChanges(t *testing.T) {
+	app := &Application{
+		Log: nopLogger{},
+		Config: config.NewConfigFromMap(map[string]interface{}{
+			"features": map[string]interface{}{"new_checkout": true, "beta_search": "off"},
+		}),
+	}
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if !app.Feature("new_checkout") || app.Feature("beta_search") || app.Feature("missing") {
+		t.Fatal("Feature mismatch")
+	}
+
+	var changes []bool
+	app.OnFeatureChange("beta_search", func(enabled bool) { changes = append(changes, enabled) })
+	app.Config.Set("features.beta_search", true)
+	app.Config.Set("features.new_checkout", false)
+	if len(changes) != 1 || !changes[0] || !app.Feature("beta_search") || app.Feature("new_checkout") {
+		t.Fatalf("changes = %v", changes)
+	}
+}
+
+func TestInitRejectsInvalidFeatures(t *testing.T) {
+	app := &Application{
+		Log: nopLogger{},
+		Config: config.NewConfigFromMap(map[string]interface{}{
+			"features": map[string]interface{}{"new_checkout": "maybe"},
+		}),
+	}
+	if err := app.Init(); err == nil {
+		t.Fatal("Init accepted a non-boolean feature flag")
+	}
+}
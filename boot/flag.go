@@ -0,0 +1,108 @@
+package boot
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// appFlags 应用启动时支持的命令行参数
+type appFlags struct {
+	registered      bool
+	skipCommandLine bool
+	conf            string
+	env             string
+	sets            setFlag
+	printConfig     bool
+}
+
+// setFlag 可以重复指定的 -set key=value 参数
+type setFlag []string
+
+func (s *setFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *setFlag) Set(v string) error {
+	if i := strings.Index(v, "="); i <= 0 {
+		return fmt.Errorf("格式应为 key=value: %s", v)
+	}
+	*s = append(*s, v)
+	return nil
+}
+
+// RegisterFlags 将应用的命令行参数注册到 fs 中，需要与业务自定义的参数一起解析时使用
+//
+//	-conf          配置文件所在的目录，或配置文件的路径
+//	-env           应用的运行环境，优先于 HULK_ENV 环境变量及 app.env
+//	-set           覆盖配置项，格式为 key=value，可以重复指定，优先于环境变量及配置文件
+//	-print-config  输出合并后的配置内容及每个配置项的来源后退出
+//
+// 注册后由调用方负责解析，Run 时直接使用解析后的取值
+//
+//	app.RegisterFlags(flag.CommandLine)
+//	flag.Parse()
+func (app *Application) RegisterFlags(fs *flag.FlagSet) {
+	app.flags.registered = true
+	fs.StringVar(&app.flags.conf, "conf", "", "配置文件所在的目录，或配置文件的路径")
+	fs.StringVar(&app.flags.env, "env", "", "应用的运行环境，如 dev、test、prod")
+	fs.Var(&app.flags.sets, "set", "覆盖配置项，格式为 key=value，可以重复指定")
+	fs.BoolVar(&app.flags.printConfig, "print-config", false, "输出合并后的配置内容后退出")
+}
+
+// WithoutCommandLine 禁止 Run 自动解析 os.Args，命令行参数完全由业务自行处理时使用
+func WithoutCommandLine() AppOption {
+	return func(app *Application) {
+		app.flags.skipCommandLine = true
+	}
+}
+
+// ParseFlags 解析命令行参数，args 中只能包含应用的命令行参数
+func (app *Application) ParseFlags(args []string) error {
+	fs := flag.NewFlagSet(app.Name, flag.ContinueOnError)
+	app.RegisterFlags(fs)
+	return fs.Parse(args)
+}
+
+// parseCommandLine 在 Run 时将命令行参数应用到配置上，默认使用 os.Args 解析应用的命令行参数，以下情况除外：
+// 1. 已调用 RegisterFlags，由调用方负责解析
+// 2. 设置了 WithoutCommandLine
+// 3. flag.CommandLine 已经被解析，说明业务定义并解析了自己的命令行参数（go test 同理），应改用 RegisterFlags 注册应用的参数
+func (app *Application) parseCommandLine() error {
+	if !app.flags.registered && !app.flags.skipCommandLine && !flag.Parsed() {
+		if err := app.ParseFlags(os.Args[1:]); err != nil {
+			return err
+		}
+	}
+	return app.applyFlags()
+}
+
+// applyFlags 将命令行参数应用到应用的配置上，需在 Init 之前调用
+func (app *Application) applyFlags() error {
+	if app.flags.conf != "" {
+		fi, err := os.Stat(app.flags.conf)
+		if err != nil {
+			return fmt.Errorf("-conf 指定的配置路径不可用 err: %v", err)
+		}
+		if fi.IsDir() {
+			WithConfigPath(app.flags.conf)(app)
+		} else {
+			WithConfigFile(app.flags.conf)(app)
+		}
+	}
+	if app.flags.env != "" {
+		app.Env = app.flags.env
+	}
+	for _, kv := range app.flags.sets {
+		i := strings.Index(kv, "=")
+		app.Config.Set(kv[:i], kv[i+1:])
+	}
+	return nil
+}
+
+// printConfig 加载配置后将合并后的配置内容输出到标准输出
+func (app *Application) printConfig() error {
+	if err := app.Init(); err != nil {
+		return err
+	}
+	return app.Config.Dump(os.Stdout)
+}
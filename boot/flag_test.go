@@ -0,0 +1,117 @@
+package boot
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liuyuanxiang/go-hulc/config"
+)
+
+func TestParseFlags(t *testing.T) {
+	dir := tempDir(t)
+	content := "app:\n  env: dev\ngrpc:\n  port: 9000\nmongo:\n  host: 127.0.0.1\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HULK_GRPC_PORT", "9100")
+	defer os.Unsetenv("HULK_GRPC_PORT")
+
+	app := &Application{Log: nopLogger{}, Config: config.NewConfig()}
+	err := app.ParseFlags([]string{"-conf", dir, "-env", "test", "-set", "grpc.port=9200", "-set", "mongo.host=mongo.internal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.applyFlags(); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// -set 优先于环境变量及配置文件
+	if got := app.Config.GetInt("grpc.port"); got != 9200 {
+		t.Fatalf("grpc.port = %d, want 9200", got)
+	}
+	if got := app.Config.GetStringMap("mongo")["host"]; got != "mongo.internal" {
+		t.Fatalf("mongo.host = %v", got)
+	}
+	if got := app.Config.Env(); got != "test" {
+		t.Fatalf("env = %q, want test", got)
+	}
+	if got := app.Config.Source("grpc.port"); got != "override" {
+		t.Fatalf("Source(grpc.port) = %q", got)
+	}
+}
+
+func TestParseFlagsConfFile(t *testing.T) {
+	dir := tempDir(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, "order.yaml"), []byte("app:\n  name: order\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &Application{Log: nopLogger{}, Config: config.NewConfig()}
+	if err := app.ParseFlags([]string{"-conf", filepath.Join(dir, "order.yaml")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.applyFlags(); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if got := app.Config.GetString("app.name"); got != "order" {
+		t.Fatalf("app.name = %q, want order", got)
+	}
+}
+
+func TestParseFlagsInvalidSet(t *testing.T) {
+	app := &Application{Log: nopLogger{}, Config: config.NewConfig()}
+	if err := app.ParseFlags([]string{"-set", "grpc.port"}); err == nil {
+		t.Fatal("-set without = should fail")
+	}
+}
+
+func TestParseCommandLineSkipped(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "-test.v", "-env", "test"}
+
+	// go test 已经解析了 flag.CommandLine，Run 不再解析 os.Args，其中的 -test.* 参数不会导致启动失败
+	app := &Application{Log: nopLogger{}, Config: config.NewConfig()}
+	if err := app.parseCommandLine(); err != nil {
+		t.Fatal(err)
+	}
+	if app.Env != "" {
+		t.Fatalf("env = %q, want empty", app.Env)
+	}
+
+	app = &Application{Log: nopLogger{}, Config: config.NewConfig()}
+	WithoutCommandLine()(app)
+	if err := app.parseCommandLine(); err != nil || app.Env != "" {
+		t.Fatalf("env = %q, err = %v with WithoutCommandLine", app.Env, err)
+	}
+}
+
+func TestRegisterFlagsOnCallerFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	port := fs.Int("port", 0, "业务自定义参数")
+	app := &Application{Log: nopLogger{}, Config: config.NewConfig()}
+	app.RegisterFlags(fs)
+	if err := fs.Parse([]string{"-port", "8080", "-env", "prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "-port", "8080"}
+	// 已通过 RegisterFlags 注册时使用调用方解析的结果，不再解析 os.Args
+	if err := app.parseCommandLine(); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 8080 || app.Env != "prod" {
+		t.Fatalf("port = %d, env = %q", *port, app.Env)
+	}
+}
@@ -7,14 +7,25 @@ import (
 
 	"github.com/liuyuanxiang/go-hulc/logger"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
+// NewGateway 创建一个 gRPC-Gateway 的 ServeMux，错误日志记录到 logger 包的默认实例
 func NewGateway() *runtime.ServeMux {
+	return newGateway(logger.Logger)
+}
+
+// NewGateway 创建一个 gRPC-Gateway 的 ServeMux，错误日志记录到应用的日志处理器
+func (app *Application) NewGateway() *runtime.ServeMux {
+	return newGateway(func() logger.LogInterface { return app.Log })
+}
+
+func newGateway(lg func() logger.LogInterface) *runtime.ServeMux {
 	return runtime.NewServeMux(
-		runtime.WithErrorHandler(customHTTPError),
+		runtime.WithErrorHandler(httpErrorHandler(lg)),
 		runtime.WithForwardResponseOption(cors),
 	)
 }
@@ -32,12 +43,32 @@ type httpErrorResponse struct {
 	ErrDetail string      `json:"errDetail,omitempty"`
 }
 
-func customHTTPError(_ context.Context, _ *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
-	s, ok := status.FromError(err)
-	if !ok {
-		s = status.New(codes.Unknown, err.Error())
+// httpErrorHandler 返回将 gRPC 错误转换为统一格式 HTTP 响应的错误处理函数
+// 日志处理器在每次处理时获取，应用创建后通过 SetLogger 替换日志处理器同样生效
+func httpErrorHandler(lg func() logger.LogInterface) runtime.ErrorHandlerFunc {
+	return func(_ context.Context, _ *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+		s, ok := status.FromError(err)
+		if !ok {
+			s = status.New(codes.Unknown, err.Error())
+		}
+
+		response := newHTTPErrorResponse(s)
+		if s.Message() != "" {
+			lg().Error("gRPC-Gateway http err:", s.Message())
+		}
+
+		jsonMsg, _ := json.Marshal(response)
+		w.Header().Set("Content-Type", marshaler.ContentType(s.Proto()))
+		w.WriteHeader(runtime.HTTPStatusFromCode(s.Code()))
+		if _, err = w.Write(jsonMsg); err != nil {
+			lg().Error("gRPC-Gateway response write err:", err, s.Message())
+		}
 	}
+}
 
+// newHTTPErrorResponse 将 gRPC 错误状态转换为统一格式的 HTTP 错误响应内容
+// 请求参数校验失败时，字段错误清单会放在 data 中返回
+func newHTTPErrorResponse(s *status.Status) *httpErrorResponse {
 	response := &httpErrorResponse{
 		ErrCode: 10000,
 		Message: "Unkonwn",
@@ -52,15 +83,33 @@ func customHTTPError(_ context.Context, _ *runtime.ServeMux, marshaler runtime.M
 		// if config.Env == "test" || config.Env == "dev" {
 		// 	response.ErrDetail = s.Message()
 		// }
-		logger.Error("gRPC-Gateway http err:", s.Message())
 	}
 
-	jsonMsg, _ := json.Marshal(response)
-	w.Header().Set("Content-Type", marshaler.ContentType(s.Proto()))
-	w.WriteHeader(runtime.HTTPStatusFromCode(s.Code()))
-	if _, err = w.Write(jsonMsg); err != nil {
-		logger.Error("gRPC-Gateway response write err:", err, s.Message())
+	if fields := fieldErrorsFromStatus(s); len(fields) > 0 {
+		response.Data = fields
+	}
+	return response
+}
+
+// fieldError 请求参数校验失败时，返回给 HTTP 调用方的单个字段错误
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// fieldErrorsFromStatus 从 gRPC 错误详情中提取 errdetails.BadRequest 的字段错误清单
+func fieldErrorsFromStatus(s *status.Status) []fieldError {
+	var fields []fieldError
+	for _, d := range s.Details() {
+		br, ok := d.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, v := range br.GetFieldViolations() {
+			fields = append(fields, fieldError{Field: v.GetField(), Message: v.GetDescription()})
+		}
 	}
+	return fields
 }
 
 // HTTP 接口服务跨域处理
@@ -1,7 +1,97 @@
 package boot
 
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
 // Run 启动并运行一个基于 Gin 框架实现的 HTTP Server 服务
+// 启动前会应用 -conf、-env、-set 及 -print-config 命令行参数，参见 RegisterFlags 及 WithoutCommandLine
 func (app *GinApplication) Run() error {
-	// TODO
+	if err := app.parseCommandLine(); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if app.flags.printConfig {
+		return app.printConfig()
+	}
+
+	cors := defaultCORSConfig()
+	app.Config.Require("http", &listenConfig{})
+	app.Config.Require("http.cors", &cors)
+	if err := app.Setup(); err != nil {
+		return err
+	}
+
+	// 应用优雅退出
+	defer app.gracefulStop()
+
+	lis, err := app.listen("http")
+	if err != nil {
+		return fmt.Errorf("Run ginServer err: %v", err)
+	}
+	app.HTTPServer = &http.Server{Handler: app.GinEngin}
+
+	errChan := make(chan error, 1)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
+
+	app.Log.Debug(app.Name, "服务启动... 监听地址:", lis.Addr())
+
+	go func() {
+		if err := app.HTTPServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+			errChan <- fmt.Errorf("Run ginServer err: http.Server 启动异常: %v", err)
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-quit:
+	}
+
+	return nil
+}
+
+// Setup 执行应用初始化、创建 Gin Engine 并注册路由、启动定时任务，但不监听任何端口
+// Run 会自动调用，由外部自行管理 Listener 时（例如测试）可以单独调用后直接使用 GinEngin
+func (app *GinApplication) Setup() error {
+	if err := app.Init(); err != nil {
+		return fmt.Errorf("Gin 应用初始化失败 err: %v", err)
+	}
+	if app.GinEngin == nil {
+		app.GinEngin = app.NewGinEngine()
+	}
+	if app.RegisterRoute != nil {
+		if err := app.RegisterRoute(app.GinEngin); err != nil {
+			return fmt.Errorf("Gin 路由注册失败 err: %v", err)
+		}
+	}
+	if err := app.startScheduler(); err != nil {
+		return fmt.Errorf("Gin 定时任务启动失败 err: %v", err)
+	}
 	return nil
 }
+
+// Stop 优雅退出应用，与 Setup 配合使用
+func (app *GinApplication) Stop() { app.gracefulStop() }
+
+// gracefulStop 应用优雅退出
+func (app *GinApplication) gracefulStop() {
+	if app.HTTPServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := app.HTTPServer.Shutdown(ctx); err != nil {
+			app.Log.Error("HTTPServer shutdown err:", err)
+		}
+	}
+	app.stopScheduler()
+	app.runStopHooks()
+}
@@ -0,0 +1,184 @@
+package boot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// httpResponse 处理成功时返回给 HTTP 调用方的统一响应格式，与 httpErrorResponse 保持一致
+type httpResponse struct {
+	ErrCode int64       `json:"errcode"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+// ErrCoder 可以自定义 errcode 的业务错误，未实现时 errcode 统一为 10000
+type ErrCoder interface {
+	ErrCode() int64
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Handle 将形如 func(context.Context, *Req) (*Resp, error) 的强类型处理函数转换为 gin.HandlerFunc
+//
+//	engine.POST("/v1/users/:id", boot.Handle(func(ctx context.Context, req *UpdateUserReq) (*User, error) {
+//		...
+//	}))
+//
+// Req 的字段依次从查询参数（form 标签）、请求头（header 标签）、请求体及路径参数（uri 标签）中绑定，路径参数优先，
+// 全部绑定完成后再根据 binding 标签统一校验。ctx 为请求的上下文，携带链路追踪 ID 及超时时间。
+// 处理成功时返回 {errcode: 0, message: "ok", data: Resp}，失败时返回与 gRPC-Gateway 一致的错误格式。
+// fn 的签名不符合要求时会直接 panic，与 Gin 注册非法路由时的行为一致
+func Handle(fn interface{}) gin.HandlerFunc {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 2 || ft.NumOut() != 2 ||
+		ft.In(0) != contextType || ft.In(1).Kind() != reflect.Ptr || ft.In(1).Elem().Kind() != reflect.Struct ||
+		ft.Out(1) != errorType {
+		panic(fmt.Sprintf("boot.Handle: %s 不是 func(context.Context, *Req) (*Resp, error) 形式的处理函数", ft))
+	}
+
+	reqType := ft.In(1).Elem()
+	bindURI := hasFieldTag(reqType, "uri")
+	bindHeader := hasFieldTag(reqType, "header")
+
+	return func(c *gin.Context) {
+		req := reflect.New(reqType)
+		if err := bindRequest(c, req.Interface(), bindURI, bindHeader); err != nil {
+			renderError(c, err)
+			return
+		}
+
+		out := fv.Call([]reflect.Value{reflect.ValueOf(c.Request.Context()), req})
+		if err, _ := out[1].Interface().(error); err != nil {
+			renderError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, &httpResponse{Message: "ok", Data: out[0].Interface()})
+	}
+}
+
+// bindRequest 从请求的各个部分绑定参数，最后统一进行结构体校验
+// 依次绑定查询参数、请求头、请求体及路径参数，路径参数最后绑定，不会被查询参数或请求体中的同名字段覆盖，
+// 以保证处理函数与鉴权中间件通过 c.Param 看到的是同一个值
+// 单个来源绑定时 Gin 会执行一次校验，此时其他来源的字段尚未绑定，因此忽略其中的校验错误
+func bindRequest(c *gin.Context, obj interface{}, bindURI, bindHeader bool) error {
+	if err := ignoreValidation(c.ShouldBindQuery(obj)); err != nil {
+		return err
+	}
+	if bindHeader {
+		if err := ignoreValidation(c.ShouldBindHeader(obj)); err != nil {
+			return err
+		}
+	}
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := ignoreValidation(c.ShouldBindWith(obj, binding.Default(c.Request.Method, c.ContentType()))); err != nil {
+			return err
+		}
+	}
+	if bindURI && len(c.Params) > 0 {
+		if err := ignoreValidation(c.ShouldBindUri(obj)); err != nil {
+			return err
+		}
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// ignoreValidation 忽略单个来源绑定时的校验错误，其他错误标记为参数绑定错误
+func ignoreValidation(err error) error {
+	var ve validator.ValidationErrors
+	if err == nil || errors.As(err, &ve) {
+		return nil
+	}
+	return bindError{err}
+}
+
+func hasFieldTag(t reflect.Type, tag string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := f.Tag.Lookup(tag); ok {
+			return true
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && hasFieldTag(f.Type, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderError 将处理函数返回的错误转换为统一格式的错误响应
+// gRPC 状态错误按 gRPC-Gateway 的规则映射 HTTP 状态码，参数绑定及校验错误返回 400
+func renderError(c *gin.Context, err error) {
+	s := statusFromError(err)
+	response := newHTTPErrorResponse(s)
+
+	var coder ErrCoder
+	if errors.As(err, &coder) {
+		response.ErrCode = coder.ErrCode()
+	}
+
+	c.Error(err)
+	c.AbortWithStatusJSON(runtime.HTTPStatusFromCode(s.Code()), response)
+}
+
+func statusFromError(err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+
+	var ve validator.ValidationErrors
+	if errors.As(err, &ve) {
+		return validationStatus(ve)
+	}
+	if isBindError(err) {
+		return status.New(codes.InvalidArgument, err.Error())
+	}
+	return status.New(codes.Unknown, err.Error())
+}
+
+// validationStatus 将结构体校验错误转换为携带 errdetails.BadRequest 的 codes.InvalidArgument 状态
+// 与 gRPC 请求参数校验的错误格式保持一致
+func validationStatus(ve validator.ValidationErrors) *status.Status {
+	br := &errdetails.BadRequest{}
+	for _, fe := range ve {
+		// Namespace 以请求结构体的类型名开头，返回给调用方时去掉
+		field := fe.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fe.Error(),
+		})
+	}
+	s, err := status.New(codes.InvalidArgument, "请求参数校验失败").WithDetails(br)
+	if err != nil {
+		return status.New(codes.InvalidArgument, ve.Error())
+	}
+	return s
+}
+
+// bindError 标记请求参数绑定过程中产生的错误
+type bindError struct{ error }
+
+func (e bindError) Unwrap() error { return e.error }
+
+func isBindError(err error) bool {
+	var be bindError
+	return errors.As(err, &be)
+}
@@ -0,0 +1,120 @@
+package boot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type updateUserReq struct {
+	ID      int64  `uri:"id" binding:"required"`
+	Verbose bool   `form:"verbose"`
+	Tenant  string `header:"X-Tenant" binding:"required"`
+	Name    string `json:"name" binding:"required,max=8"`
+}
+
+type user struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Tenant string `json:"tenant"`
+}
+
+type codeErr struct{}
+
+func (codeErr) Error() string  { return "user is frozen" }
+func (codeErr) ErrCode() int64 { return 20001 }
+
+func newHandlerTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.POST("/users/:id", Handle(func(ctx context.Context, req *updateUserReq) (*user, error) {
+		switch req.Name {
+		case "missing":
+			return nil, status.Error(codes.NotFound, "user not found")
+		case "frozen":
+			return nil, codeErr{}
+		}
+		return &user{ID: req.ID, Name: req.Name, Tenant: req.Tenant}, nil
+	}))
+	return e
+}
+
+func doJSON(e *gin.Engine, path, body, tenant string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if tenant != "" {
+		req.Header.Set("X-Tenant", tenant)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var resp map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+func TestHandleBindsAllSources(t *testing.T) {
+	rec, resp := doJSON(newHandlerTestEngine(), "/users/42?verbose=true", `{"name":"alice"}`, "acme")
+	if rec.Code != http.StatusOK || resp["errcode"].(float64) != 0 {
+		t.Fatalf("response = %d %s", rec.Code, rec.Body)
+	}
+	data := resp["data"].(map[string]interface{})
+	if data["id"].(float64) != 42 || data["name"] != "alice" || data["tenant"] != "acme" {
+		t.Fatalf("data = %v", data)
+	}
+}
+
+func TestHandlePathParamsTakePrecedence(t *testing.T) {
+	rec, resp := doJSON(newHandlerTestEngine(), "/users/42?ID=9&id=9", `{"id":7,"ID":7,"name":"alice"}`, "acme")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("response = %d %s", rec.Code, rec.Body)
+	}
+	if id := resp["data"].(map[string]interface{})["id"].(float64); id != 42 {
+		t.Fatalf("id = %v, want the path parameter 42", id)
+	}
+}
+
+func TestHandleValidationErrors(t *testing.T) {
+	rec, resp := doJSON(newHandlerTestEngine(), "/users/42", `{"name":"a-very-long-name"}`, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	fields, _ := resp["data"].([]interface{})
+	if len(fields) != 2 {
+		t.Fatalf("want Tenant and Name violations, got %v", resp["data"])
+	}
+
+	rec, _ = doJSON(newHandlerTestEngine(), "/users/42", `{"name":`, "acme")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body status = %d", rec.Code)
+	}
+}
+
+func TestHandleErrorMapping(t *testing.T) {
+	rec, resp := doJSON(newHandlerTestEngine(), "/users/1", `{"name":"missing"}`, "acme")
+	if rec.Code != http.StatusNotFound || resp["message"] != "user not found" || resp["errcode"].(float64) != 10000 {
+		t.Fatalf("response = %d %s", rec.Code, rec.Body)
+	}
+
+	rec, resp = doJSON(newHandlerTestEngine(), "/users/1", `{"name":"frozen"}`, "acme")
+	if rec.Code != http.StatusInternalServerError || resp["errcode"].(float64) != 20001 {
+		t.Fatalf("response = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHandleRejectsInvalidSignature(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for an invalid handler signature")
+		}
+	}()
+	Handle(func(req *updateUserReq) error { return errors.New("x") })
+}
@@ -0,0 +1,262 @@
+package boot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liuyuanxiang/go-hulc/logger"
+)
+
+// TraceIDHeader 用于在上下游之间传递链路追踪 ID 的请求头
+const TraceIDHeader = "X-Trace-Id"
+
+// NewGinEngine 创建一个挂载了标准中间件的 Gin Engine
+// 中间件依次为：链路追踪 ID、访问日志、panic 恢复、跨域处理、限流、超时控制、统一错误响应
+// 访问日志位于 panic 恢复之外，因此 panic 产生的 500 响应同样会被记录
+func (app *Application) NewGinEngine() *gin.Engine {
+	ginOutputOnce.Do(app.redirectGinOutput)
+
+	engine := gin.New()
+	engine.Use(
+		TraceMiddleware(),
+		app.AccessLogMiddleware(),
+		app.RecoveryMiddleware(),
+		app.CORSMiddleware(),
+		app.RateLimitMiddleware(),
+		app.TimeoutMiddleware(),
+		ErrorMiddleware(),
+	)
+	engine.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, &httpErrorResponse{ErrCode: 10000, Message: "Not Found"})
+	})
+	return engine
+}
+
+// ginOutputOnce Gin 的调试输出是进程级的，无法按应用区分，由第一个创建 Gin Engine 的应用接管
+var ginOutputOnce sync.Once
+
+// redirectGinOutput 将 Gin 的调试输出、错误输出及路由注册信息转交给应用的日志处理器
+// 不改变 Gin 的运行模式，是否产生调试输出仍由 GIN_MODE 环境变量或 gin.SetMode 决定
+func (app *Application) redirectGinOutput() {
+	gin.DefaultWriter = &logWriter{log: app.Log.Info}
+	gin.DefaultErrorWriter = &logWriter{log: app.Log.Error}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		app.Log.Debug("Gin 路由注册:", method, path, "-->", handler, "handlers:", handlers)
+	}
+}
+
+// logWriter 将每次写入的内容作为一条日志记录
+type logWriter struct {
+	log func(v ...interface{})
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	if msg := strings.TrimRight(string(p), "\n"); msg != "" {
+		w.log(msg)
+	}
+	return len(p), nil
+}
+
+// TraceMiddleware 从请求头中读取链路追踪 ID，不存在时生成一个新的 ID
+// 追踪 ID 会写入请求上下文及响应头中，可以通过 logger.TraceIDFromContext 获取
+func TraceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID := c.GetHeader(TraceIDHeader)
+		if traceID == "" {
+			traceID = newTraceID()
+		}
+		c.Request = c.Request.WithContext(logger.ContextWithTraceID(c.Request.Context(), traceID))
+		c.Header(TraceIDHeader, traceID)
+		c.Next()
+	}
+}
+
+func newTraceID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RecoveryMiddleware 恢复处理请求过程中发生的 panic，记录携带链路追踪 ID 的错误日志并返回统一格式的 500 响应
+func (app *Application) RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				log := logger.Structured(app.Log).WithContext(c.Request.Context())
+				log.Error("HTTP 请求处理 panic:", c.Request.Method, c.Request.URL.Path, r, string(debug.Stack()))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, &httpErrorResponse{
+					ErrCode: 10000,
+					Message: "Internal Server Error",
+				})
+			}
+		}()
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware 记录每一次请求的访问日志
+// 日志处理器实现了 logger.RequestLogInterface 时（如 ilog）写入 request 日志，否则以 Info 级别记录
+func (app *Application) AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		duration := time.Since(start)
+
+		r := c.Request
+		entry := &logger.RequestLog{
+			TraceID:         logger.TraceIDFromContext(r.Context()),
+			Method:          r.Method,
+			URL:             r.URL.Path,
+			StatusCode:      c.Writer.Status(),
+			Duration:        duration.Seconds(),
+			RequestTime:     start.Format("2006-01-02 15:04:05"),
+			QueryString:     r.URL.RawQuery,
+			UserAgent:       r.UserAgent(),
+			ReferURL:        r.Referer(),
+			ClientIP:        c.ClientIP(),
+			ResponseHeaders: flattenHeader(c.Writer.Header()),
+		}
+
+		if rl, ok := app.Log.(logger.RequestLogInterface); ok {
+			rl.Request(entry)
+			return
+		}
+		app.Log.Info(entry.TraceID, entry.ClientIP, entry.Method, entry.URL, entry.StatusCode, duration)
+	}
+}
+
+func flattenHeader(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k := range h {
+		m[k] = h.Get(k)
+	}
+	return m
+}
+
+// corsConfig HTTP 接口服务的跨域配置，未配置的项与 gRPC-Gateway 的跨域处理保持一致
+//
+//	http:
+//	  cors:
+//	    allow_origins: ["https://example.com"]
+//	    allow_methods: [GET, POST, OPTIONS]
+//	    allow_headers: [Content-Type, Authorization]
+//	    expose_headers: [Content-Length]
+//	    allow_credentials: true
+//	    max_age: 12h
+//
+// allow_credentials 默认关闭，开启时只对 allow_origins 中明确列出的来源携带凭证，* 匹配的来源不会携带凭证
+type corsConfig struct {
+	AllowOrigins     []string      `mapstructure:"allow_origins"`
+	AllowMethods     []string      `mapstructure:"allow_methods"`
+	AllowHeaders     []string      `mapstructure:"allow_headers"`
+	ExposeHeaders    []string      `mapstructure:"expose_headers"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age"`
+}
+
+func defaultCORSConfig() corsConfig {
+	return corsConfig{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"POST", "GET", "OPTIONS"},
+		AllowHeaders:  []string{"Content-Type", "AccessToken", "X-CSRF-Token", "Authorization", "Token"},
+		ExposeHeaders: []string{"Content-Length", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Content-Type"},
+	}
+}
+
+// corsConfig 读取 http.cors 配置，未配置的项使用默认值
+func (app *Application) corsConfig() corsConfig {
+	cfg := defaultCORSConfig()
+	if err := app.Config.Bind("http.cors", &cfg); err != nil {
+		app.Log.Error("http.cors 配置格式错误 err:", err)
+	}
+	return cfg
+}
+
+// CORSMiddleware 根据 http.cors 配置进行跨域处理，并直接响应允许的来源发起的 OPTIONS 预检请求
+// 不被允许的来源不会得到跨域响应头，其 OPTIONS 请求交由后续的路由处理
+func (app *Application) CORSMiddleware() gin.HandlerFunc {
+	cfg := app.corsConfig()
+
+	var maxAge string
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		allowed := allowOrigin(cfg.AllowOrigins, origin)
+		if allowed == "" {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Access-Control-Allow-Origin", allowed)
+		h.Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowHeaders, ", "))
+		h.Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowMethods, ", "))
+		h.Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposeHeaders, ", "))
+		if cfg.AllowCredentials && allowed != "*" {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if maxAge != "" {
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		if allowed != "*" {
+			h.Add("Vary", "Origin")
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowOrigin 返回 Access-Control-Allow-Origin 的取值，请求来源不被允许时返回空字符串
+// 明确列出的来源回写请求中的来源，只被 * 匹配的来源返回 *，浏览器不会为 * 携带凭证
+func allowOrigin(allowed []string, origin string) string {
+	wildcard := false
+	for _, o := range allowed {
+		if o == "*" {
+			wildcard = true
+			continue
+		}
+		if origin != "" && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	if wildcard {
+		return "*"
+	}
+	return ""
+}
+
+// ErrorMiddleware 将处理过程中通过 c.Error 记录且尚未响应的错误，统一渲染为 httpErrorResponse 格式
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		code := c.Writer.Status()
+		if code < http.StatusBadRequest {
+			code = http.StatusInternalServerError
+		}
+		err := c.Errors.Last()
+		c.JSON(code, &httpErrorResponse{
+			ErrCode:   10000,
+			Message:   err.Error(),
+			ErrDetail: strings.Join(c.Errors.Errors(), "; "),
+		})
+	}
+}
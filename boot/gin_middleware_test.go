@@ -0,0 +1,200 @@
+package boot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liuyuanxiang/go-hulc/config"
+	"github.com/liuyuanxiang/go-hulc/logger"
+)
+
+func newTestGinApp(t *testing.T, m map[string]interface{}) *GinApplication {
+	t.Helper()
+
+	app := &GinApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfigFromMap(m)}}
+	app.RegisterRoute = func(e *gin.Engine) error {
+		e.GET("/panic", func(*gin.Context) { panic("boom") })
+		e.GET("/error", func(c *gin.Context) { c.Error(errors.New("bad thing")) })
+		e.GET("/trace", func(c *gin.Context) { c.String(http.StatusOK, logger.TraceIDFromContext(c.Request.Context())) })
+		return nil
+	}
+	if err := app.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func serve(app *GinApplication, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	app.GinEngin.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGinMiddlewareRendersErrors(t *testing.T) {
+	app := newTestGinApp(t, nil)
+
+	rec := serve(app, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), `"errcode":10000`) {
+		t.Fatalf("panic response = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(app, httptest.NewRequest(http.MethodGet, "/error", nil))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), `"message":"bad thing"`) {
+		t.Fatalf("error response = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(app, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `"errcode"`) {
+		t.Fatalf("not found response = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGinMiddlewarePropagatesTraceID(t *testing.T) {
+	app := newTestGinApp(t, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/trace", nil)
+	req.Header.Set(TraceIDHeader, "abc123")
+	rec := serve(app, req)
+	if rec.Body.String() != "abc123" || rec.Header().Get(TraceIDHeader) != "abc123" {
+		t.Fatalf("trace id not propagated: body %q header %q", rec.Body, rec.Header().Get(TraceIDHeader))
+	}
+
+	rec = serve(app, httptest.NewRequest(http.MethodGet, "/trace", nil))
+	if rec.Body.Len() != 32 || rec.Header().Get(TraceIDHeader) != rec.Body.String() {
+		t.Fatalf("trace id not generated: body %q", rec.Body)
+	}
+}
+
+func TestGinMiddlewareCORS(t *testing.T) {
+	app := newTestGinApp(t, map[string]interface{}{
+		"http": map[string]interface{}{
+			"cors": map[string]interface{}{
+				"allow_origins": []interface{}{"https://example.com"},
+				"max_age":       "1h",
+			},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/trace", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec := serve(app, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight status = %d", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://example.com" || rec.Header().Get("Access-Control-Max-Age") != "3600" {
+		t.Fatalf("unexpected CORS headers: %v", rec.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/trace", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	if rec := serve(app, req); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("origin should not be allowed: %v", rec.Header())
+	}
+}
+
+func TestGinMiddlewareCORSCredentials(t *testing.T) {
+	// 默认配置及 * 搭配 allow_credentials 时都不会回写来源或携带凭证
+	for _, m := range []map[string]interface{}{
+		nil,
+		{"http": map[string]interface{}{"cors": map[string]interface{}{"allow_credentials": true}}},
+	} {
+		app := newTestGinApp(t, m)
+		req := httptest.NewRequest(http.MethodGet, "/trace", nil)
+		req.Header.Set("Origin", "https://evil.example")
+		rec := serve(app, req)
+		if rec.Header().Get("Access-Control-Allow-Origin") != "*" || rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+			t.Fatalf("config %v: unexpected CORS headers: %v", m, rec.Header())
+		}
+	}
+
+	app := newTestGinApp(t, map[string]interface{}{
+		"http": map[string]interface{}{
+			"cors": map[string]interface{}{
+				"allow_origins":     []interface{}{"https://example.com"},
+				"allow_credentials": true,
+			},
+		},
+	})
+	req := httptest.NewRequest(http.MethodGet, "/trace", nil)
+	req.Header.Set("Origin", "https://example.com")
+	if rec := serve(app, req); rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("credentials should be allowed for listed origins: %v", rec.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/trace", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	if rec := serve(app, req); rec.Code == http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("preflight from a disallowed origin = %d %v", rec.Code, rec.Header())
+	}
+}
+
+// requestLogger 记录访问日志的日志处理器
+type requestLogger struct {
+	nopLogger
+	entries []*logger.RequestLog
+}
+
+func (l *requestLogger) Request(r *logger.RequestLog) { l.entries = append(l.entries, r) }
+
+func TestGinMiddlewareLogsPanics(t *testing.T) {
+	app := newTestGinApp(t, nil)
+	lg := &requestLogger{}
+	app.Log = lg
+
+	serve(app, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if len(lg.entries) != 1 || lg.entries[0].StatusCode != http.StatusInternalServerError {
+		t.Fatalf("access log entries = %+v, want the 500 from the panic", lg.entries)
+	}
+}
+
+// lineLogger 记录每一条日志的日志处理器
+type lineLogger struct {
+	nopLogger
+	lines []string
+}
+
+func (l *lineLogger) Debug(v ...interface{}) {
+	l.lines = append(l.lines, strings.TrimSpace(fmt.Sprintln(v...)))
+}
+func (l *lineLogger) Info(v ...interface{}) {
+	l.lines = append(l.lines, strings.TrimSpace(fmt.Sprintln(v...)))
+}
+func (l *lineLogger) Warn(v ...interface{}) {
+	l.lines = append(l.lines, strings.TrimSpace(fmt.Sprintln(v...)))
+}
+
+func TestGinDebugOutputGoesToAppLogger(t *testing.T) {
+	mode := gin.Mode()
+	writer, errWriter, routeFunc := gin.DefaultWriter, gin.DefaultErrorWriter, gin.DebugPrintRouteFunc
+	defer func() {
+		gin.SetMode(mode)
+		gin.DefaultWriter, gin.DefaultErrorWriter, gin.DebugPrintRouteFunc = writer, errWriter, routeFunc
+		ginOutputOnce = sync.Once{}
+	}()
+	ginOutputOnce = sync.Once{}
+	gin.SetMode(gin.DebugMode)
+
+	lg := &lineLogger{}
+	app := &GinApplication{Application: Application{Log: lg, Config: config.NewConfigFromMap(nil)}}
+	app.RegisterRoute = func(e *gin.Engine) error {
+		e.GET("/users/:id", func(c *gin.Context) {})
+		return nil
+	}
+	if err := app.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	if gin.Mode() != gin.DebugMode {
+		t.Fatalf("gin mode = %q, want the caller's debug mode kept", gin.Mode())
+	}
+	out := strings.Join(lg.lines, "\n")
+	if !strings.Contains(out, "[GIN-debug]") || !strings.Contains(out, "Gin 路由注册: GET /users/:id") {
+		t.Fatalf("log = %q, want gin debug output and routes", out)
+	}
+}
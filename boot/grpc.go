@@ -2,53 +2,183 @@ package boot
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
-	"github.com/liuyuanxiang/go-hulc/util"
+	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
 )
 
-func NewGRPCServer() *grpc.Server {
-	return grpc.NewServer()
+// NewGRPCServer 创建一个 gRPC Server，opts 通常来自 GRPCApplication.ServerOptions
+func NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	return grpc.NewServer(opts...)
+}
+
+// WithServerOptions 额外设置创建 gRPC Server 时使用的选项，会追加在配置文件中 grpc.server 生成的选项之后
+func WithServerOptions(opts ...grpc.ServerOption) GRPCAppOption {
+	return func(g *GRPCApplication) {
+		g.serverOpts = append(g.serverOpts, opts...)
+	}
+}
+
+// ServerOptions 返回应用内置的 gRPC Server 选项，包括限流、超时控制、请求参数校验等拦截器
+func (app *GRPCApplication) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			app.rateLimitUnaryInterceptor,
+			app.timeoutUnaryInterceptor,
+			validateUnaryInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			app.rateLimitStreamInterceptor,
+			app.timeoutStreamInterceptor,
+			validateStreamInterceptor,
+		),
+	}
+}
+
+// HTTPHandler 返回 HTTP 接口服务使用的 Handler，附加了超时控制，并为流式路由挂载 WebSocket 及 SSE 桥接
+// 开启 Gateway 时 gRPC-Gateway 挂载在 / 下，挂载了 Gin Engine 时，其路径前缀下的请求交由 Gin 处理
+func (app *GRPCApplication) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	if app.isOpenGateway {
+		mux = NewGatewayServerMux(app.GatewayServeMux)
+	}
+	if app.GinEngin != nil {
+		// 前缀已在 Setup 中校验，这里不再重复返回错误
+		prefixes, _ := app.ginPrefixes()
+		for _, prefix := range prefixes {
+			mux.Handle(prefix, app.GinEngin)
+		}
+	}
+	return app.streamHandler(mux, app.timeoutHandler(mux))
+}
+
+// WithGin 在 gRPC 应用的 HTTP 接口服务中额外挂载一个 Gin Engine，用于提供 Webhook、OAuth 回调、页面等非 protobuf 定义的接口
+// prefixes 下的请求交由 Gin 处理，可以被配置文件中的 http.gin.prefixes 覆盖，Gin 中注册的路由需要包含完整的路径前缀
+// 开启 Gateway 时 / 由 Gateway 处理，需要至少指定一个 / 以外的前缀；未开启 Gateway 且未指定前缀时 Gin 处理全部请求
+// Gin Engine 会挂载与 GinApplication 相同的标准中间件，并与 Gateway 共用同一个 HTTP Server 的端口及优雅退出
+func WithGin(register func(*gin.Engine) error, prefixes ...string) GRPCAppOption {
+	return func(g *GRPCApplication) {
+		g.RegisterRoute = register
+		g.GinPrefixes = prefixes
+	}
+}
+
+// ginPrefixes 返回 Gin Engine 挂载的路径前缀，前缀统一以 / 结尾以匹配其下的所有路径
+// 开启 Gateway 时前缀不能为空或与 Gateway 的 / 冲突，前缀重复时同样返回错误
+func (app *GRPCApplication) ginPrefixes() ([]string, error) {
+	prefixes := app.GinPrefixes
+	if v := app.Config.GetStringSlice("http.gin.prefixes"); len(v) > 0 {
+		prefixes = v
+	}
+	if len(prefixes) == 0 {
+		if app.isOpenGateway {
+			return nil, fmt.Errorf("开启 Gateway 时需要通过 WithGin 或 http.gin.prefixes 指定 Gin 的路径前缀")
+		}
+		return []string{"/"}, nil
+	}
+
+	result := make([]string, 0, len(prefixes))
+	seen := make(map[string]bool, len(prefixes))
+	for _, p := range prefixes {
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		if !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+		if p == "/" && app.isOpenGateway {
+			return nil, fmt.Errorf("Gin 的路径前缀 / 与 Gateway 冲突，需要指定如 /webhook 的路径前缀")
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("Gin 的路径前缀 %s 重复", p)
+		}
+		seen[p] = true
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// requireConfig 声明运行服务所需的监听配置，开启 HTTP 接口服务时同时声明 http 下的相关配置
+// 在 Init 中与其他配置一起校验，监听前即可发现端口缺失或格式错误等问题
+func (app *GRPCApplication) requireConfig() {
+	app.Config.Require("grpc", &listenConfig{})
+	if app.isOpenHTTP() || app.RegisterRoute != nil {
+		cors := defaultCORSConfig()
+		app.Config.Require("http", &listenConfig{})
+		app.Config.Require("http.stream", &streamConfig{Routes: app.streamRoutes})
+		app.Config.Require("http.cors", &cors)
+	}
+}
+
+// isOpenHTTP 开启了 Gateway 或挂载了 Gin Engine 时，需要额外启动 HTTP 接口服务
+func (app *GRPCApplication) isOpenHTTP() bool {
+	return app.isOpenGateway || app.GinEngin != nil
 }
 
 // Run 启动并运行一个 gRPC 服务
+// 启动前会应用 -conf、-env、-set 及 -print-config 命令行参数，参见 RegisterFlags 及 WithoutCommandLine
 func (app *GRPCApplication) Run() error {
-	if err := app.Init(); err != nil {
-		return fmt.Errorf("gRPC 应用初始化失败 err: %v", err)
+	if err := app.parseCommandLine(); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
 	}
-	if err := app.executeRegisterFunc(); err != nil {
-		return fmt.Errorf("gRPC 执行预加载的注册函数失败 err: %v", err)
+	if app.flags.printConfig {
+		return app.printConfig()
+	}
+
+	app.requireConfig()
+	if err := app.Setup(); err != nil {
+		return err
+	}
+
+	// 应用优雅退出
+	defer app.gracefulStop()
+
+	grpcLis, err := app.listen("grpc")
+	if err != nil {
+		return fmt.Errorf("Run gRPCServer err: %v", err)
+	}
+	var httpLis net.Listener
+	if app.isOpenHTTP() {
+		if httpLis, err = app.listen("http"); err != nil {
+			grpcLis.Close()
+			return fmt.Errorf("Run gatewayServer err: %v", err)
+		}
+		app.HTTPServer = &http.Server{
+			Handler: app.HTTPHandler(),
+		}
 	}
 
-	errChan := make(chan error)
+	errChan := make(chan error, 2)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 
 	app.Log.Debug(app.Name, "服务启动...")
 
 	go func() {
-		if app.isOpenGateway {
+		if httpLis != nil {
 			go func() {
-				if err := app.runGatewayServer(); err != nil {
+				if err := app.runGatewayServer(httpLis); err != nil {
 					errChan <- fmt.Errorf("Run gatewayServer err: %v", err)
 				}
 			}()
 		}
 
-		if err := app.runGRPCServer(); err != nil {
+		if err := app.runGRPCServer(grpcLis); err != nil {
 			errChan <- fmt.Errorf("Run gRPCServer err: %v", err)
 		}
 	}()
 
-	// 应用优雅退出
-	defer app.gracefulStop()
-
 	select {
 	case err := <-errChan:
 		return err
@@ -58,6 +188,56 @@ func (app *GRPCApplication) Run() error {
 	return nil
 }
 
+// Setup 执行应用初始化、预加载的注册函数并启动定时任务，但不监听任何端口
+// Run 会自动调用，由外部自行管理 Listener 时（例如测试）可以单独调用后再执行 GRPCServer.Serve
+func (app *GRPCApplication) Setup() error {
+	if err := app.Init(); err != nil {
+		return fmt.Errorf("gRPC 应用初始化失败 err: %v", err)
+	}
+	if err := app.newGRPCServer(); err != nil {
+		return fmt.Errorf("gRPC Server 创建失败 err: %v", err)
+	}
+	if err := app.executeRegisterFunc(); err != nil {
+		return fmt.Errorf("gRPC 执行预加载的注册函数失败 err: %v", err)
+	}
+	if err := app.startScheduler(); err != nil {
+		return fmt.Errorf("gRPC 定时任务启动失败 err: %v", err)
+	}
+	return nil
+}
+
+// DefaultGRPCServer 创建一个使用应用内置选项及 WithServerOptions 选项的 gRPC Server，应用创建时会自动调用
+// 创建后即可直接向 GRPCServer 注册服务；配置文件中存在 grpc.server 配置时，Setup 会使用包含这些配置的 Server 替换它
+func (app *GRPCApplication) DefaultGRPCServer() *grpc.Server {
+	app.defaultServer = NewGRPCServer(append(app.ServerOptions(), app.serverOpts...)...)
+	return app.defaultServer
+}
+
+// newGRPCServer 在配置加载完成后，根据 grpc.server 配置创建 gRPC Server
+// 如果已经通过其他方式设置了 GRPCServer，则直接使用；应用创建时的默认 Server 在没有 grpc.server 配置时同样直接使用
+// 已有服务直接注册到默认 Server 时无法迁移到新的 Server，此时 grpc.server 配置无法生效并返回错误
+func (app *GRPCApplication) newGRPCServer() error {
+	opts, err := serverOptionsFromConfig(app.Config)
+	if err != nil {
+		return err
+	}
+	if app.GRPCServer != nil {
+		if app.GRPCServer != app.defaultServer || len(opts) == 0 {
+			return nil
+		}
+		if len(app.GRPCServer.GetServiceInfo()) > 0 {
+			return fmt.Errorf("grpc.server 配置需要在创建 GRPCServer 时生效，直接注册到 GRPCServer 的服务需要改为通过 RegisterGRPCServer 注册")
+		}
+	}
+
+	opts = append(app.ServerOptions(), opts...)
+	app.GRPCServer = NewGRPCServer(append(opts, app.serverOpts...)...)
+	return nil
+}
+
+// Stop 优雅退出应用，与 Setup 配合使用
+func (app *GRPCApplication) Stop() { app.gracefulStop() }
+
 func (app *GRPCApplication) OpenGateway()  { app.isOpenGateway = true }
 func (app *GRPCApplication) CloseGateway() { app.isOpenGateway = false }
 
@@ -72,41 +252,25 @@ func WithGateway(yes bool) GRPCAppOption {
 	}
 }
 
-// runGRPCServer 运行 gRPC Server 端服务
-func (app *GRPCApplication) runGRPCServer() error {
-	port := app.Config.GetInt64("grpc.port")
-	if port == 0 {
-		return fmt.Errorf("监听端口异常")
-	}
-
-	conn, err := net.Listen("tcp", util.GetPortString(port))
-	if err != nil {
-		return fmt.Errorf("TCP Listen err: %v", err)
-	}
+// GRPCAddr 返回 gRPC 服务实际监听的地址，服务启动前返回 nil
+// 配置为 :0 时可以通过该方法获取系统实际分配的端口
+func (app *GRPCApplication) GRPCAddr() net.Addr { return app.boundAddr("grpc") }
 
-	app.Log.Debug("gRPC API 启动... 监听端口:", port)
+// runGRPCServer 运行 gRPC Server 端服务
+func (app *GRPCApplication) runGRPCServer(lis net.Listener) error {
+	app.Log.Debug("gRPC API 启动... 监听地址:", lis.Addr())
 
-	if err := app.GRPCServer.Serve(conn); err != nil {
+	if err := app.GRPCServer.Serve(lis); err != nil {
 		return fmt.Errorf("gRPCServer.server 启动异常: %v", err)
 	}
 	return nil
 }
 
 // runGatewayServer 运行用于提供 HTTP 接口服务的 gRPC-Gateway Server 端服务
-func (app *GRPCApplication) runGatewayServer() error {
-	port := app.Config.GetInt64("http.port")
-	if port == 0 {
-		return fmt.Errorf("监听端口异常")
-	}
-
-	app.HTTPServer = &http.Server{
-		Addr:    util.GetPortString(port),
-		Handler: NewGatewayServerMux(app.GatewayServeMux),
-	}
-
-	app.Log.Debug("HTTP API 启动... 监听端口:", port)
+func (app *GRPCApplication) runGatewayServer(lis net.Listener) error {
+	app.Log.Debug("HTTP API 启动... 监听地址:", lis.Addr())
 
-	if err := app.HTTPServer.ListenAndServe(); err != nil {
+	if err := app.HTTPServer.Serve(lis); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("http.Server 启动异常: %v", err)
 	}
 	return nil
@@ -125,7 +289,11 @@ func (app *GRPCApplication) gracefulStop() {
 			app.Log.Error("HTTPServer shutdown err:", err)
 		}
 	}
-	app.GRPCServer.GracefulStop()
+	if app.GRPCServer != nil {
+		app.GRPCServer.GracefulStop()
+	}
+	app.stopScheduler()
+	app.runStopHooks()
 }
 
 // executeRegisterFunc 执行应用下相关的注册函数
@@ -138,5 +306,16 @@ func (app *GRPCApplication) executeRegisterFunc() error {
 			return err
 		}
 	}
+	if app.RegisterRoute != nil {
+		if _, err := app.ginPrefixes(); err != nil {
+			return err
+		}
+		if app.GinEngin == nil {
+			app.GinEngin = app.NewGinEngine()
+		}
+		if err := app.RegisterRoute(app.GinEngin); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,243 @@
+package boot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/liuyuanxiang/go-hulc/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+// serverOptionsFromConfig 根据配置文件中的 grpc.server 配置生成 gRPC Server 选项
+// 所有配置项校验失败的原因会合并为一个错误返回
+//
+//	grpc:
+//	  server:
+//	    max_recv_msg_size: 16MB
+//	    max_send_msg_size: 16MB
+//	    max_concurrent_streams: 1000
+//	    connection_timeout: 120s
+//	    read_buffer_size: 32KB
+//	    write_buffer_size: 32KB
+//	    compressors: [gzip, zstd]
+//	    keepalive:
+//	      max_connection_idle: 15m
+//	      max_connection_age: 30m
+//	      max_connection_age_grace: 5s
+//	      time: 2h
+//	      timeout: 20s
+//	    keepalive_enforcement:
+//	      min_time: 5m
+//	      permit_without_stream: false
+func serverOptionsFromConfig(c *config.Config) ([]grpc.ServerOption, error) {
+	r := &optionReader{c: c, prefix: "grpc.server."}
+	var opts []grpc.ServerOption
+
+	if n, ok := r.readBytes("max_recv_msg_size", math.MaxInt32); ok {
+		opts = append(opts, grpc.MaxRecvMsgSize(int(n)))
+	}
+	if n, ok := r.readBytes("max_send_msg_size", math.MaxInt32); ok {
+		opts = append(opts, grpc.MaxSendMsgSize(int(n)))
+	}
+	if n, ok := r.readBytes("read_buffer_size", math.MaxInt32); ok {
+		opts = append(opts, grpc.ReadBufferSize(int(n)))
+	}
+	if n, ok := r.readBytes("write_buffer_size", math.MaxInt32); ok {
+		opts = append(opts, grpc.WriteBufferSize(int(n)))
+	}
+	if n, ok := r.readInt("max_concurrent_streams", math.MaxUint32); ok {
+		opts = append(opts, grpc.MaxConcurrentStreams(uint32(n)))
+	}
+	if d, ok := r.readDuration("connection_timeout"); ok {
+		opts = append(opts, grpc.ConnectionTimeout(d))
+	}
+
+	var kp keepalive.ServerParameters
+	kpSet := false
+	for key, d := range map[string]*time.Duration{
+		"keepalive.max_connection_idle":      &kp.MaxConnectionIdle,
+		"keepalive.max_connection_age":       &kp.MaxConnectionAge,
+		"keepalive.max_connection_age_grace": &kp.MaxConnectionAgeGrace,
+		"keepalive.time":                     &kp.Time,
+		"keepalive.timeout":                  &kp.Timeout,
+	} {
+		if v, ok := r.readDuration(key); ok {
+			*d = v
+			kpSet = true
+		}
+	}
+	if kpSet {
+		opts = append(opts, grpc.KeepaliveParams(kp))
+	}
+
+	var ep keepalive.EnforcementPolicy
+	epSet := false
+	if d, ok := r.readDuration("keepalive_enforcement.min_time"); ok {
+		ep.MinTime = d
+		epSet = true
+	}
+	if v, ok := r.readBool("keepalive_enforcement.permit_without_stream"); ok {
+		ep.PermitWithoutStream = v
+		epSet = true
+	}
+	if epSet {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(ep))
+	}
+
+	compressors, err := c.GetStringSliceE(r.prefix + "compressors")
+	r.failed(err)
+	for _, name := range compressors {
+		if encoding.GetCompressor(name) == nil {
+			r.errs = append(r.errs, fmt.Sprintf("%scompressors: 不支持的压缩算法 %s", r.prefix, name))
+		}
+	}
+
+	if len(r.errs) > 0 {
+		return nil, fmt.Errorf("grpc.server 配置校验失败: %s", strings.Join(r.errs, "; "))
+	}
+	return opts, nil
+}
+
+// optionReader 按类型读取配置项，并收集所有格式错误
+type optionReader struct {
+	c      *config.Config
+	prefix string
+	errs   []string
+}
+
+func (r *optionReader) readBytes(key string, max int64) (int64, bool) {
+	n, err := r.c.GetByteSizeE(r.prefix + key)
+	if r.failed(err) {
+		return 0, false
+	}
+	return r.inRange(key, int64(n), max)
+}
+
+func (r *optionReader) readInt(key string, max int64) (int64, bool) {
+	n, err := r.c.GetInt64E(r.prefix + key)
+	if r.failed(err) {
+		return 0, false
+	}
+	return r.inRange(key, n, max)
+}
+
+// inRange 检查配置项的取值是否在 (0, max] 的范围内
+func (r *optionReader) inRange(key string, n, max int64) (int64, bool) {
+	if n <= 0 || n > max {
+		r.errs = append(r.errs, fmt.Sprintf("%s%s: 取值 %d 超出范围 (0, %d]", r.prefix, key, n, max))
+		return 0, false
+	}
+	return n, true
+}
+
+func (r *optionReader) readDuration(key string) (time.Duration, bool) {
+	d, err := r.c.GetDurationE(r.prefix + key)
+	if r.failed(err) {
+		return 0, false
+	}
+	if d <= 0 {
+		r.errs = append(r.errs, fmt.Sprintf("%s%s: 无效的时间间隔 %v", r.prefix, key, d))
+		return 0, false
+	}
+	return d, true
+}
+
+func (r *optionReader) readBool(key string) (bool, bool) {
+	b, err := r.c.GetBoolE(r.prefix + key)
+	return b, !r.failed(err)
+}
+
+// failed 记录读取配置项时的类型错误，配置项不存在时不视为错误
+func (r *optionReader) failed(err error) bool {
+	if err == nil {
+		return false
+	}
+	if !errors.Is(err, config.ErrNotSet) {
+		r.errs = append(r.errs, err.Error())
+	}
+	return true
+}
+
+const zstdName = "zstd"
+
+// encoding.RegisterCompressor 只能在 init 阶段调用，zstd 在包初始化时统一注册，gzip 在导入 grpc/encoding/gzip 时已经完成注册
+// grpc.server.compressors 只校验配置的压缩算法是否可用，客户端声明支持后服务端即可使用
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// zstdCompressor 基于 klauspost/compress 实现的 gRPC zstd 压缩算法
+// gRPC 的消息本身已完整位于内存中，这里使用 EncodeAll/DecodeAll 一次性完成压缩及解压：
+// 进程内共享一个 Encoder 及 Decoder，它们内部按并发数复用编解码状态，调用过程中不会创建 goroutine，
+// 也不存在需要读取到 EOF 才能释放的资源；每次调用使用的缓冲区通过 sync.Pool 复用
+type zstdCompressor struct{}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+
+	zstdWriterPool = sync.Pool{New: func() interface{} { return &zstdWriter{} }}
+)
+
+// zstdCodec 第一次使用时创建共享的 Encoder 及 Decoder，Decoder 会常驻与并发数相同的 goroutine
+// 解压后的内容不超过 gRPC 允许的最大消息大小，避免恶意构造的数据耗尽内存
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder) {
+	zstdOnce.Do(func() {
+		zstdEncoder, _ = zstd.NewWriter(nil)
+		zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(math.MaxInt32))
+	})
+	return zstdEncoder, zstdDecoder
+}
+
+func (*zstdCompressor) Name() string { return zstdName }
+
+func (*zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	z := zstdWriterPool.Get().(*zstdWriter)
+	z.w = w
+	z.buf.Reset()
+	return z, nil
+}
+
+func (*zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	z := zstdWriterPool.Get().(*zstdWriter)
+	defer zstdWriterPool.Put(z)
+	z.buf.Reset()
+	if _, err := z.buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	_, dec := zstdCodec()
+	out, err := dec.DecodeAll(z.buf.Bytes(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(out), nil
+}
+
+// zstdWriter 缓存待压缩的消息，Close 时压缩并写入 w 后放回 zstdWriterPool
+type zstdWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+	dst []byte
+}
+
+func (z *zstdWriter) Write(p []byte) (int, error) { return z.buf.Write(p) }
+
+func (z *zstdWriter) Close() error {
+	defer zstdWriterPool.Put(z)
+	enc, _ := zstdCodec()
+	z.dst = enc.EncodeAll(z.buf.Bytes(), z.dst[:0])
+	_, err := z.w.Write(z.dst)
+	z.w = nil
+	return err
+}
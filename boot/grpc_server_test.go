@@ -0,0 +1,144 @@
+package boot
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/liuyuanxiang/go-hulc/config"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestServerOptionsFromConfig(t *testing.T) {
+	c := config.NewConfigFromMap(map[string]interface{}{
+		"grpc": map[string]interface{}{
+			"server": map[string]interface{}{
+				"max_recv_msg_size":      "16MB",
+				"max_send_msg_size":      8388608,
+				"max_concurrent_streams": 100,
+				"connection_timeout":     "30s",
+				"compressors":            []interface{}{"gzip", "zstd"},
+				"keepalive": map[string]interface{}{
+					"time":    "2h",
+					"timeout": "20s",
+				},
+				"keepalive_enforcement": map[string]interface{}{
+					"min_time":              "5m",
+					"permit_without_stream": true,
+				},
+			},
+		},
+	})
+
+	opts, err := serverOptionsFromConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts) != 6 {
+		t.Fatalf("len(opts) = %d, want 6", len(opts))
+	}
+	if encoding.GetCompressor("zstd") == nil {
+		t.Fatal("zstd compressor was not registered")
+	}
+}
+
+func TestServerOptionsFromConfigReportsAllErrors(t *testing.T) {
+	c := config.NewConfigFromMap(map[string]interface{}{
+		"grpc": map[string]interface{}{
+			"server": map[string]interface{}{
+				"max_recv_msg_size":      "lots",
+				"max_concurrent_streams": -1,
+				"connection_timeout":     "soon",
+				"compressors":            []interface{}{"brotli"},
+			},
+		},
+	})
+
+	_, err := serverOptionsFromConfig(c)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, key := range []string{"max_recv_msg_size", "max_concurrent_streams", "connection_timeout", "brotli"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q does not mention %s", err, key)
+		}
+	}
+}
+
+func TestDefaultGRPCServerKeepsDirectRegistrations(t *testing.T) {
+	app := &GRPCApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfigFromMap(nil)}}
+	app.GRPCServer = app.DefaultGRPCServer()
+	healthpb.RegisterHealthServer(app.GRPCServer, health.NewServer())
+	server := app.GRPCServer
+
+	if err := app.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	defer app.Stop()
+	if app.GRPCServer != server {
+		t.Fatal("Setup replaced the server that services were registered on")
+	}
+
+	app = &GRPCApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfigFromMap(map[string]interface{}{
+		"grpc": map[string]interface{}{"server": map[string]interface{}{"max_concurrent_streams": 10}},
+	})}}
+	app.GRPCServer = app.DefaultGRPCServer()
+	healthpb.RegisterHealthServer(app.GRPCServer, health.NewServer())
+	if err := app.Setup(); err == nil || !strings.Contains(err.Error(), "RegisterGRPCServer") {
+		t.Fatalf("Setup err = %v, want grpc.server options that cannot be applied to be reported", err)
+	}
+}
+
+func TestZstdCompressorRoundTrip(t *testing.T) {
+	c := encoding.GetCompressor("zstd")
+	msg := []byte(strings.Repeat("hulk zstd round trip ", 1000))
+
+	roundTrip := func() {
+		var buf bytes.Buffer
+		w, err := c.Compress(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() >= len(msg) {
+			t.Fatalf("compressed size = %d, want < %d", buf.Len(), len(msg))
+		}
+
+		r, err := c.Decompress(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 只读取一部分，不读取到 EOF 也不会占用资源
+		part := make([]byte, 10)
+		if _, err := io.ReadFull(r, part); err != nil || string(part) != string(msg[:10]) {
+			t.Fatalf("part = %q, err = %v", part, err)
+		}
+		rest, err := ioutil.ReadAll(r)
+		if err != nil || !bytes.Equal(append(part, rest...), msg) {
+			t.Fatalf("round trip mismatch, err = %v", err)
+		}
+	}
+
+	roundTrip()
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		roundTrip()
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Fatalf("goroutines = %d after round trips, want <= %d", n, goroutines)
+	}
+
+	if _, err := c.Decompress(strings.NewReader("not zstd")); err == nil {
+		t.Fatal("Decompress accepted invalid data")
+	}
+}
@@ -0,0 +1,147 @@
+package boot
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/liuyuanxiang/go-hulc/config"
+	"github.com/liuyuanxiang/go-hulc/logger"
+)
+
+// GRPCOption 可以用于创建 GRPCApplication 的选项，GRPCAppOption 及通用的 AppOption 都实现了该接口
+type GRPCOption interface {
+	applyGRPC(*GRPCApplication)
+}
+
+// GinOption 可以用于创建 GinApplication 的选项，GinAppOption 及通用的 AppOption 都实现了该接口
+type GinOption interface {
+	applyGin(*GinApplication)
+}
+
+// GRPCAppOption 仅适用于 GRPCApplication 的选项
+type GRPCAppOption func(*GRPCApplication)
+
+func (o GRPCAppOption) applyGRPC(app *GRPCApplication) { o(app) }
+
+// GinAppOption 仅适用于 GinApplication 的选项
+type GinAppOption func(*GinApplication)
+
+func (o GinAppOption) applyGin(app *GinApplication) { o(app) }
+
+// AppOption 同时适用于 GRPCApplication 及 GinApplication 的通用选项
+type AppOption func(*Application)
+
+func (o AppOption) applyGRPC(app *GRPCApplication) { o(&app.Application) }
+func (o AppOption) applyGin(app *GinApplication)   { o(&app.Application) }
+
+// ApplyGRPCOptions 依次将选项应用到 GRPCApplication 上
+func ApplyGRPCOptions(app *GRPCApplication, opts ...GRPCOption) {
+	for _, opt := range opts {
+		opt.applyGRPC(app)
+	}
+}
+
+// ApplyGinOptions 依次将选项应用到 GinApplication 上
+func ApplyGinOptions(app *GinApplication, opts ...GinOption) {
+	for _, opt := range opts {
+		opt.applyGin(app)
+	}
+}
+
+// ensureConfig 返回应用的 Config，未设置时创建一个新的 Config，避免选项在 Config 为 nil 时 panic
+func (app *Application) ensureConfig() *config.Config {
+	if app.Config == nil {
+		app.Config = config.NewConfig()
+	}
+	return app.Config
+}
+
+// WithConfigPath 设置配置文件所在的目录，默认为项目下的 ./config/
+func WithConfigPath(path string) AppOption {
+	return func(app *Application) {
+		app.ensureConfig().SetLoadPath(path)
+	}
+}
+
+// WithConfigFile 设置应用加载的配置文件，默认为 app.yaml
+// 文件名中包含目录时，同时设置配置文件所在的目录
+func WithConfigFile(file string) AppOption {
+	return func(app *Application) {
+		if dir := filepath.Dir(file); dir != "." {
+			app.ensureConfig().SetLoadPath(dir)
+		}
+		app.ConfigFile = filepath.Base(file)
+	}
+}
+
+// WithConfigFiles 设置在主配置文件之后加载的其他配置文件，后加载的文件优先，文件名相对于配置文件所在的目录
+func WithConfigFiles(files ...string) AppOption {
+	return func(app *Application) {
+		app.ConfigFiles = append(app.ConfigFiles, files...)
+	}
+}
+
+// WithConfigFS 在加载配置文件之前从 fsys 中加载内嵌的配置，通常配合 go:embed 为应用提供默认配置
+// 内嵌配置的优先级低于所有配置文件
+func WithConfigFS(fsys fs.FS, files ...string) AppOption {
+	return func(app *Application) {
+		app.configLoaders = append(app.configLoaders, func() error {
+			return app.ensureConfig().LoadFS(fsys, files...)
+		})
+	}
+}
+
+// WithEnvPrefix 设置通过环境变量覆盖配置项时使用的前缀，默认为 HULK
+func WithEnvPrefix(prefix string) AppOption {
+	return func(app *Application) {
+		app.ensureConfig().SetEnvPrefix(prefix)
+	}
+}
+
+// WithConfigSource 添加配置来源，如从配置中心读取配置的 config.NewHTTPSource
+// 多个来源在配置文件之后按添加的顺序合并，优先于配置文件
+func WithConfigSource(src config.Source) AppOption {
+	return func(app *Application) {
+		_ = app.ensureConfig().AddSource(src)
+	}
+}
+
+// WithSecretResolver 设置解析配置中 secret:// 引用使用的 SecretResolver
+func WithSecretResolver(r config.SecretResolver) AppOption {
+	return func(app *Application) {
+		app.ensureConfig().SetSecretResolver(r)
+	}
+}
+
+// WithLogger 将应用的日志处理器设置为一个 LogInterface 接口的自定义实现
+func WithLogger(lg logger.LogInterface) AppOption {
+	return func(app *Application) {
+		app.Log = lg
+	}
+}
+
+// WithLogPath 设置应用日志文件的存放目录，未通过 WithLogger 设置日志处理器时生效
+func WithLogPath(path string) AppOption {
+	return func(app *Application) {
+		app.LogPath = path
+	}
+}
+
+// WithEnv 设置应用的运行环境，优先于 HULK_ENV 环境变量及配置文件中的 app.env，同时决定加载的环境配置文件
+func WithEnv(env string) AppOption {
+	return func(app *Application) {
+		app.Env = env
+	}
+}
+
+// SetupLogger 未设置日志处理器时，根据 LogPath 创建默认的日志处理器
+func (app *Application) SetupLogger() {
+	if app.Log != nil {
+		return
+	}
+	if app.LogPath == "" || app.LogPath == logger.DefaultLogSavePath {
+		app.Log = logger.Logger()
+		return
+	}
+	app.Log = logger.NewLogger(app.LogPath)
+}
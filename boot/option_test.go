@@ -0,0 +1,255 @@
+package boot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liuyuanxiang/go-hulc/config"
+	"github.com/liuyuanxiang/go-hulc/logger"
+)
+
+func TestAppOptionsApplyToBothApps(t *testing.T) {
+	lg := nopLogger{}
+	opts := []AppOption{WithLogger(lg), WithLogPath("/tmp/logs"), WithEnv("test"), WithConfigFile("conf/dev.yaml")}
+
+	g := &GRPCApplication{Application: Application{Config: config.NewConfigFromMap(nil)}}
+	r := &GinApplication{Application: Application{Config: config.NewConfigFromMap(nil)}}
+	for _, opt := range opts {
+		ApplyGRPCOptions(g, opt)
+		ApplyGinOptions(r, opt)
+	}
+
+	for _, app := range []*Application{&g.Application, &r.Application} {
+		if app.Log != lg || app.LogPath != "/tmp/logs" || app.Env != "test" || app.ConfigFile != "dev.yaml" {
+			t.Fatalf("unexpected application: %+v", app)
+		}
+	}
+}
+
+func TestWithConfigFileLoadsConfig(t *testing.T) {
+	dir := tempDir(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, "gin.yaml"), []byte("app:\n  env: dev\n  name: demo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &GinApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfig()}}
+	ApplyGinOptions(app, WithConfigFile(filepath.Join(dir, "gin.yaml")), WithEnv("prod"))
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := app.Config.GetString("app.name"); got != "demo" {
+		t.Fatalf("app.name = %q, want demo", got)
+	}
+	if !app.Config.IsProdEnv() {
+		t.Fatal("WithEnv should override app.env from the config file")
+	}
+}
+
+func TestSetupLogger(t *testing.T) {
+	lg := nopLogger{}
+	app := &Application{Log: lg}
+	app.SetupLogger()
+	if app.Log != lg {
+		t.Fatal("SetupLogger should keep the logger set by WithLogger")
+	}
+
+	app = &Application{LogPath: tempDir(t)}
+	app.SetupLogger()
+	if app.Log == nil {
+		t.Fatal("SetupLogger should create a logger for LogPath")
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "hulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestOnStopRunsHooksOnceInReverseOrder(t *testing.T) {
+	app := &GinApplication{Application: Application{Log: nopLogger{}}}
+
+	var calls []int
+	app.OnStop(func() { calls = append(calls, 1) })
+	app.OnStop(func() { calls = append(calls, 2) })
+
+	app.Stop()
+	app.Stop()
+
+	if len(calls) != 2 || calls[0] != 2 || calls[1] != 1 {
+		t.Fatalf("calls = %v, want [2 1]", calls)
+	}
+}
+
+func TestInitFailsOnConfigErrors(t *testing.T) {
+	dir := tempDir(t)
+	app := &GRPCApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfig()}}
+	ApplyGRPCOptions(app, WithConfigPath(dir))
+	if err := app.Init(); err == nil {
+		t.Fatal("Init succeeded without app.yaml")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte("grpc:\n  prot: 9100\ntimeout:\n  default: 1.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app = &GRPCApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfig()}}
+	ApplyGRPCOptions(app, WithConfigPath(dir))
+	app.requireConfig()
+	err := app.Init()
+	if err == nil || !strings.Contains(err.Error(), "grpc.port") || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("Init error = %v, want grpc.port and timeout problems", err)
+	}
+}
+
+func TestWithConfigFSAndFiles(t *testing.T) {
+	dir := tempDir(t)
+	for name, content := range map[string]string{
+		"app.yaml":   "app:\n  name: demo\n",
+		"mongo.yaml": "mongo:\n  host: db\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defaults := fstest.MapFS{"defaults.yaml": {Data: []byte("app:\n  name: default\n  owner: infra\n")}}
+
+	app := &GinApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfig()}}
+	ApplyGinOptions(app, WithConfigPath(dir), WithConfigFiles("mongo.yaml"), WithConfigFS(defaults, "defaults.yaml"))
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.Config.GetString("app.name") != "demo" || app.Config.GetString("app.owner") != "infra" || app.Config.GetString("mongo.host") != "db" {
+		t.Fatalf("app = %v, mongo = %v", app.Config.GetStringMap("app"), app.Config.GetStringMap("mongo"))
+	}
+}
+
+func TestLogLevelFromConfig(t *testing.T) {
+	dir := tempDir(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte("logger:\n  level: debug\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logImpl := logger.NewLogger(dir)
+	lg := logImpl.(logger.Leveler)
+	app := &GinApplication{Application: Application{Log: logImpl, Config: config.NewConfig()}}
+	ApplyGinOptions(app, WithConfigPath(dir))
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if lg.GetLevel() != logger.DebugLevel {
+		t.Fatalf("level = %v, want debug", lg.GetLevel())
+	}
+
+	app.Config.Set("logger.level", "error")
+	if lg.GetLevel() != logger.ErrorLevel {
+		t.Fatalf("level after change = %v, want error", lg.GetLevel())
+	}
+	if err := app.SetLogLevel("verbose"); err == nil {
+		t.Fatal("SetLogLevel(verbose) should fail")
+	}
+}
+
+func TestLogLevelValidatedLikeParseLevel(t *testing.T) {
+	for content, ok := range map[string]bool{
+		"logger:\n  level: Info\n":    true,
+		"logger:\n  level: Warning\n": true,
+		"logger:\n  level: verbose\n": false,
+	} {
+		dir := tempDir(t)
+		if err := ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		app := &GinApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfig()}}
+		ApplyGinOptions(app, WithConfigPath(dir))
+		err := app.Init()
+		if ok && err != nil {
+			t.Fatalf("Init with %q err = %v", content, err)
+		}
+		if !ok && (err == nil || !strings.Contains(err.Error(), "logger.level")) {
+			t.Fatalf("Init with %q err = %v, want logger.level problem", content, err)
+		}
+	}
+}
+
+func TestWithGinValidatesPrefixes(t *testing.T) {
+	register := func(e *gin.Engine) error { return nil }
+	cases := []struct {
+		gateway  bool
+		prefixes []string
+		ok       bool
+	}{
+		{gateway: true, prefixes: nil},
+		{gateway: true, prefixes: []string{"/"}},
+		{gateway: true, prefixes: []string{"/hooks", "hooks/"}},
+		{gateway: true, prefixes: []string{"/hooks"}, ok: true},
+		{gateway: false, prefixes: nil, ok: true},
+	}
+	for _, c := range cases {
+		app := &GRPCApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfigFromMap(nil)}}
+		ApplyGRPCOptions(app, WithGateway(c.gateway), WithGin(register, c.prefixes...))
+		err := app.Setup()
+		if (err == nil) != c.ok {
+			t.Errorf("gateway %v prefixes %q: Setup err = %v", c.gateway, c.prefixes, err)
+		}
+		if err == nil {
+			app.HTTPHandler()
+			app.Stop()
+		}
+	}
+}
+
+func TestOptionsWithNilConfig(t *testing.T) {
+	dir := tempDir(t)
+	opts := []GRPCOption{
+		WithGateway(true),
+		WithConfigPath(dir), WithEnvPrefix("DEMO"), WithConfigSource(config.NewBytesSource("defaults.yaml", "yaml", []byte("app:\n  name: demo\n"))),
+	}
+
+	app := &GRPCApplication{Application: Application{Log: nopLogger{}}}
+	ApplyGRPCOptions(app, opts...)
+	if app.Config == nil {
+		t.Fatal("options should create the Config when it is nil")
+	}
+
+	r := &GinApplication{Application: Application{Log: nopLogger{}}}
+	ApplyGinOptions(r, WithSecretResolver(config.SecretResolverFunc(func(string) (string, error) { return "", nil })))
+	if r.Config == nil {
+		t.Fatal("options should create the Config when it is nil")
+	}
+}
+
+func TestInitAcceptsPortZero(t *testing.T) {
+	for _, content := range []string{"grpc:\n  port: 0\n", "grpc:\n  address: 127.0.0.1:0\n"} {
+		dir := tempDir(t)
+		if err := ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		app := &GRPCApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfig()}}
+		ApplyGRPCOptions(app, WithConfigPath(dir))
+		app.requireConfig()
+		if err := app.Init(); err != nil {
+			t.Fatalf("Init with %q err = %v", content, err)
+		}
+	}
+
+	dir := tempDir(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte("grpc:\n  port: 70000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := &GRPCApplication{Application: Application{Log: nopLogger{}, Config: config.NewConfig()}}
+	ApplyGRPCOptions(app, WithConfigPath(dir))
+	app.requireConfig()
+	if err := app.Init(); err == nil || !strings.Contains(err.Error(), "grpc.port") {
+		t.Fatalf("Init error = %v, want grpc.port problem", err)
+	}
+}
@@ -0,0 +1,231 @@
+package boot
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liuyuanxiang/go-hulc/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimitLogInterval 请求被限流时记录日志的最小间隔，避免大量请求被拒绝时日志刷屏
+const rateLimitLogInterval = 10 * time.Second
+
+// rateLimitConfig 请求限流配置，每个方法使用独立的令牌桶
+//
+//	ratelimit:
+//	  default: 0
+//	  burst: 0
+//	  methods:
+//	    - method: /helloworld.Greeter/SayHello
+//	      rate: 100
+//	      burst: 200
+//	    - method: /helloworld.Greeter/*
+//	      rate: 50
+//	    - method: GET /v1/users/:id
+//	      rate: 20
+//
+// rate 为每秒允许的请求数，0 表示不限制；burst 为允许的突发请求数，未配置时与 rate 相同
+// method 的写法与 timeout.methods 一致，配置重新加载后立即生效
+type rateLimitConfig struct {
+	Default float64 `mapstructure:"default" validate:"gte=0"`
+	Burst   int     `mapstructure:"burst" validate:"gte=0"`
+	Methods []struct {
+		Method string  `mapstructure:"method" validate:"required"`
+		Rate   float64 `mapstructure:"rate" validate:"gte=0"`
+		Burst  int     `mapstructure:"burst" validate:"gte=0"`
+	} `mapstructure:"methods" validate:"dive"`
+}
+
+type rateLimit struct {
+	rate  float64
+	burst float64
+}
+
+func newRateLimit(rate float64, burst int) rateLimit {
+	b := float64(burst)
+	if b <= 0 {
+		b = rate
+	}
+	if b < 1 {
+		b = 1
+	}
+	return rateLimit{rate: rate, burst: b}
+}
+
+// rateLimiter 由 ratelimit 配置生成的限流策略，令牌桶在第一次请求时按方法创建
+type rateLimiter struct {
+	def     rateLimit
+	methods map[string]rateLimit
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// loadRateLimiter 根据配置生成限流策略，未配置限流时返回 nil
+func loadRateLimiter(c *config.Config) (*rateLimiter, error) {
+	if c == nil || c.Get("ratelimit") == nil {
+		return nil, nil
+	}
+
+	var rc rateLimitConfig
+	if err := c.Bind("ratelimit", &rc); err != nil {
+		return nil, err
+	}
+
+	l := &rateLimiter{
+		def:     newRateLimit(rc.Default, rc.Burst),
+		methods: make(map[string]rateLimit),
+		buckets: make(map[string]*tokenBucket),
+	}
+	for _, m := range rc.Methods {
+		l.methods[m.Method] = newRateLimit(m.Rate, m.Burst)
+	}
+	return l, nil
+}
+
+// lookup 返回指定方法的限流设置，依次匹配完整方法名、服务名及默认值
+func (l *rateLimiter) lookup(method string) rateLimit {
+	if r, ok := l.methods[method]; ok {
+		return r
+	}
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		if r, ok := l.methods[method[:i]+"/*"]; ok {
+			return r
+		}
+	}
+	return l.def
+}
+
+// allow 判断指定方法的请求是否可以继续处理，未配置限流时总是返回 true
+func (l *rateLimiter) allow(method string) bool {
+	if l == nil {
+		return true
+	}
+	r := l.lookup(method)
+	if r.rate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[method]
+	if !ok {
+		b = &tokenBucket{tokens: r.burst, last: time.Now()}
+		l.buckets[method] = b
+	}
+	return b.take(r, time.Now())
+}
+
+// tokenBucket 令牌桶，调用方需持有 rateLimiter.mu
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(r rateLimit, now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * r.rate
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// setupRateLimit 根据 ratelimit 配置生成限流策略，并在配置变更时替换，新的配置在重新加载前已通过 Require 校验
+func (app *Application) setupRateLimit() error {
+	l, err := loadRateLimiter(app.Config)
+	if err != nil {
+		return err
+	}
+	app.setRateLimiter(l)
+
+	app.Config.OnChange("ratelimit", func(_, _ interface{}) {
+		l, err := loadRateLimiter(app.Config)
+		if err != nil {
+			app.Log.Error("ratelimit 配置变更后加载失败，继续使用原有配置 err:", err)
+			return
+		}
+		app.setRateLimiter(l)
+		app.Log.Info("ratelimit 配置已更新")
+	})
+	return nil
+}
+
+func (app *Application) setRateLimiter(l *rateLimiter) {
+	app.limitMu.Lock()
+	defer app.limitMu.Unlock()
+	app.limiter = l
+}
+
+// allowRequest 按当前的限流策略判断请求是否可以继续处理
+func (app *Application) allowRequest(method string) bool {
+	app.limitMu.RLock()
+	l := app.limiter
+	app.limitMu.RUnlock()
+	return l.allow(method)
+}
+
+// rateLimitUnaryInterceptor 对 gRPC 一元请求进行限流，超出限制时返回 codes.ResourceExhausted
+// gRPC-Gateway 会将其转换为 429 Too Many Requests
+func (app *GRPCApplication) rateLimitUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !app.allowRequest(info.FullMethod) {
+		return nil, app.rateLimited(info.FullMethod)
+	}
+	return handler(ctx, req)
+}
+
+// rateLimitStreamInterceptor 对 gRPC 流式请求的建立进行限流
+func (app *GRPCApplication) rateLimitStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !app.allowRequest(info.FullMethod) {
+		return app.rateLimited(info.FullMethod)
+	}
+	return handler(srv, ss)
+}
+
+func (app *GRPCApplication) rateLimited(method string) error {
+	app.rejected("gRPC", method)
+	return status.Errorf(codes.ResourceExhausted, "请求过于频繁: %s", method)
+}
+
+// rejected 记录被限流拒绝的请求，计入 RateLimitCount，每 rateLimitLogInterval 最多记录一次日志
+func (app *Application) rejected(kind, method string) {
+	n := atomic.AddUint64(&app.rateLimitCount, 1)
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&app.rateLimitLogged)
+	if now-last < int64(rateLimitLogInterval) || !atomic.CompareAndSwapInt64(&app.rateLimitLogged, last, now) {
+		return
+	}
+	app.Log.Warn(kind, "请求超出限流:", method, "累计拒绝请求数:", n)
+}
+
+// RateLimitCount 返回应用启动以来因超出限流被拒绝的请求数量，包括 gRPC 请求及 Gin 路由
+func (app *Application) RateLimitCount() uint64 { return atomic.LoadUint64(&app.rateLimitCount) }
+
+// RateLimitMiddleware 返回按配置对 Gin 路由进行限流的中间件，超出限制时返回 429
+// 路由的限流通过 ratelimit.methods 中 "METHOD 路径" 形式的配置覆盖，路径与注册路由时一致
+func (app *Application) RateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		if !app.allowRequest(route) {
+			app.rejected("HTTP", route)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, &httpErrorResponse{
+				ErrCode: 10000,
+				Message: "请求过于频繁",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,115 @@
+package boot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liuyuanxiang/go-hulc/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimitFollowsConfigChanges(t *testing.T) {
+	app := &GRPCApplication{Application: Application{
+		Log: nopLogger{},
+		Config: config.NewConfigFromMap(map[string]interface{}{
+			"ratelimit": map[string]interface{}{
+				"default": 1,
+				"methods": []interface{}{
+					map[string]interface{}{"method": "/test.Svc/Free", "rate": 0},
+				},
+			},
+		}),
+	}}
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	call := func(method string) error {
+		_, err := app.rateLimitUnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, ok)
+		return err
+	}
+	if err := call("/test.Svc/Limited"); err != nil {
+		t.Fatal(err)
+	}
+	if err := call("/test.Svc/Limited"); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second call error = %v, want ResourceExhausted", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := call("/test.Svc/Free"); err != nil {
+			t.Fatalf("unlimited method error = %v", err)
+		}
+	}
+
+	// 配置变更后立即使用新的限流设置
+	app.Config.Set("ratelimit.default", 0)
+	if err := call("/test.Svc/Limited"); err != nil {
+		t.Fatalf("after reload error = %v", err)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	app := &Application{
+		Log: nopLogger{},
+		Config: config.NewConfigFromMap(map[string]interface{}{
+			"ratelimit": map[string]interface{}{
+				"methods": []interface{}{
+					map[string]interface{}{"method": "GET /limited", "rate": 1, "burst": 2},
+				},
+			},
+		}),
+	}
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := gin.New()
+	engine.Use(app.RateLimitMiddleware())
+	engine.GET("/limited", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	var codes []int
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("codes = %v, want [200 200 429]", codes)
+	}
+}
+
+func TestRateLimitRejectionsAreCountedAndLogThrottled(t *testing.T) {
+	lg := &lineLogger{}
+	app := &GRPCApplication{Application: Application{
+		Log: lg,
+		Config: config.NewConfigFromMap(map[string]interface{}{
+			"ratelimit": map[string]interface{}{"default": 1},
+		}),
+	}}
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	for i := 0; i < 101; i++ {
+		app.rateLimitUnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Svc/Limited"}, ok)
+	}
+	if n := app.RateLimitCount(); n != 100 {
+		t.Fatalf("RateLimitCount() = %d, want 100", n)
+	}
+	var warned int
+	for _, line := range lg.lines {
+		if strings.Contains(line, "请求超出限流") {
+			warned++
+		}
+	}
+	if warned != 1 {
+		t.Fatalf("logged %d rejections, want 1: %q", warned, lg.lines)
+	}
+}
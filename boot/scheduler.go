@@ -0,0 +1,291 @@
+package boot
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/liuyuanxiang/go-hulc/config"
+	"github.com/liuyuanxiang/go-hulc/logger"
+	"github.com/robfig/cron/v3"
+)
+
+// JobFunc 定时任务的执行函数
+// ctx 会在应用退出或单次执行超时时被取消，任务实现需要及时响应 ctx.Done()
+type JobFunc func(ctx context.Context) error
+
+// Job 一个注册到调度器中的定时任务
+// Cron 与 Every 二选一，同时设置时以 Cron 表达式为准
+type Job struct {
+	Name     string
+	Cron     string
+	Every    time.Duration
+	Jitter   time.Duration
+	Timeout  time.Duration
+	Disabled bool
+
+	fn       JobFunc
+	schedule cron.Schedule
+	running  int32
+}
+
+type JobOption func(*Job)
+
+// WithCron 使用标准的 5 段式 cron 表达式设置任务的执行计划，同时支持 @every 1m、@daily 等描述符
+func WithCron(spec string) JobOption {
+	return func(j *Job) { j.Cron = spec }
+}
+
+// WithEvery 设置任务按固定的时间间隔执行
+func WithEvery(d time.Duration) JobOption {
+	return func(j *Job) { j.Every = d }
+}
+
+// WithJitter 为每次执行时间增加一个 [0, d) 的随机延迟，避免多实例部署时同一时刻集中执行
+func WithJitter(d time.Duration) JobOption {
+	return func(j *Job) { j.Jitter = d }
+}
+
+// WithJobTimeout 设置任务单次执行的超时时间，超时后任务的 ctx 将被取消
+func WithJobTimeout(d time.Duration) JobOption {
+	return func(j *Job) { j.Timeout = d }
+}
+
+// Scheduler 随应用生命周期启停的进程内定时任务调度器
+// 应用退出时，所有任务的 ctx 都会被取消，并等待正在执行的任务结束
+type Scheduler struct {
+	mu      sync.Mutex
+	jobs    []*Job
+	started bool
+
+	log    logger.LogInterface
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler 返回一个空的定时任务调度器
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// AddJob 注册一个定时任务，任务名称在同一个调度器中必须唯一
+// 代码中设置的执行计划可以被配置文件中 scheduler.jobs.<name> 下的同名配置覆盖
+func (s *Scheduler) AddJob(name string, fn JobFunc, opts ...JobOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("定时任务 %s 注册失败: 调度器已启动", name)
+	}
+	for _, j := range s.jobs {
+		if j.Name == name {
+			return fmt.Errorf("定时任务 %s 重复注册", name)
+		}
+	}
+
+	job := &Job{Name: name, fn: fn}
+	for _, opt := range opts {
+		opt(job)
+	}
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+// Jobs 返回已注册的定时任务清单，返回的是任务配置的副本，修改不会影响调度器中的任务
+func (s *Scheduler) Jobs() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]*Job, len(s.jobs))
+	for i, j := range s.jobs {
+		jobs[i] = &Job{
+			Name:     j.Name,
+			Cron:     j.Cron,
+			Every:    j.Every,
+			Jitter:   j.Jitter,
+			Timeout:  j.Timeout,
+			Disabled: j.Disabled,
+		}
+	}
+	return jobs
+}
+
+// Start 根据配置文件中的 scheduler.jobs 内容调整执行计划后，启动所有未被禁用的定时任务
+//
+//	scheduler:
+//	  jobs:
+//	    cleanup:
+//	      cron: "*/5 * * * *"
+//	      every: 30s
+//	      jitter: 5s
+//	      timeout: 1m
+//	      disabled: false
+func (s *Scheduler) Start(c *config.Config, lg logger.LogInterface) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return nil
+	}
+
+	for _, j := range s.jobs {
+		if err := j.applyConfig(c); err != nil {
+			return err
+		}
+		if err := j.prepare(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.log = lg
+	s.cancel = cancel
+	s.started = true
+
+	for _, j := range s.jobs {
+		if j.Disabled {
+			s.log.Info("定时任务", j.Name, "已禁用")
+			continue
+		}
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+	return nil
+}
+
+// Stop 取消所有定时任务的 ctx，并等待正在执行中的任务结束
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// loop 按任务的执行计划循环等待并触发执行，直到调度器退出
+func (s *Scheduler) loop(ctx context.Context, j *Job) {
+	defer s.wg.Done()
+
+	for {
+		timer := time.NewTimer(time.Until(j.next(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// 上一次执行尚未结束时跳过本次执行，避免同一任务重叠运行
+		if !atomic.CompareAndSwapInt32(&j.running, 0, 1) {
+			s.log.Warn("定时任务", j.Name, "上一次执行尚未结束，跳过本次执行")
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer atomic.StoreInt32(&j.running, 0)
+			s.execute(ctx, j)
+		}()
+	}
+}
+
+// execute 执行一次任务，处理单次超时及 panic 恢复，并记录执行结果及耗时
+func (s *Scheduler) execute(ctx context.Context, j *Job) {
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return j.fn(ctx)
+	}()
+	duration := time.Since(start)
+
+	if err != nil {
+		s.log.Error("定时任务", j.Name, "执行失败 耗时:", duration, "err:", err)
+		return
+	}
+	s.log.Info("定时任务", j.Name, "执行完成 耗时:", duration)
+}
+
+// applyConfig 使用配置文件中的同名任务配置覆盖代码中的设置
+func (j *Job) applyConfig(c *config.Config) error {
+	if c == nil {
+		return nil
+	}
+
+	prefix := "scheduler.jobs." + j.Name + "."
+	if spec := c.GetString(prefix + "cron"); spec != "" {
+		j.Cron = spec
+	}
+	for key, d := range map[string]*time.Duration{
+		"every":   &j.Every,
+		"jitter":  &j.Jitter,
+		"timeout": &j.Timeout,
+	} {
+		if !c.IsSet(prefix + key) {
+			continue
+		}
+		parsed, err := c.GetDurationE(prefix + key)
+		if err != nil {
+			return fmt.Errorf("定时任务 %s 配置格式错误 err: %v", j.Name, err)
+		}
+		*d = parsed
+	}
+	if c.IsSet(prefix + "disabled") {
+		disabled, err := c.GetBoolE(prefix + "disabled")
+		if err != nil {
+			return fmt.Errorf("定时任务 %s 配置格式错误 err: %v", j.Name, err)
+		}
+		j.Disabled = disabled
+	}
+	return nil
+}
+
+// prepare 校验并解析任务的执行计划
+func (j *Job) prepare() error {
+	if j.fn == nil {
+		return fmt.Errorf("定时任务 %s 缺少执行函数", j.Name)
+	}
+	if j.Cron != "" {
+		schedule, err := cron.ParseStandard(j.Cron)
+		if err != nil {
+			return fmt.Errorf("定时任务 %s cron 表达式 %q 解析失败 err: %v", j.Name, j.Cron, err)
+		}
+		j.schedule = schedule
+		return nil
+	}
+	if j.Every <= 0 {
+		return fmt.Errorf("定时任务 %s 未设置有效的执行计划", j.Name)
+	}
+	return nil
+}
+
+// next 计算任务在 t 之后的下一次执行时间
+func (j *Job) next(t time.Time) time.Time {
+	var next time.Time
+	if j.schedule != nil {
+		next = j.schedule.Next(t)
+	} else {
+		next = t.Add(j.Every)
+	}
+	if j.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(j.Jitter))))
+	}
+	return next
+}
@@ -0,0 +1,110 @@
+package boot
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(...interface{}) {}
+func (nopLogger) Info(...interface{})  {}
+func (nopLogger) Warn(...interface{})  {}
+func (nopLogger) Error(...interface{}) {}
+func (nopLogger) Fatal(...interface{}) {}
+
+func TestSchedulerPreventsOverlapAndStopsJobs(t *testing.T) {
+	s := NewScheduler()
+
+	var runs, concurrent, maxConcurrent int32
+	err := s.AddJob("slow", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		if n := atomic.AddInt32(&concurrent, 1); n > atomic.LoadInt32(&maxConcurrent) {
+			atomic.StoreInt32(&maxConcurrent, n)
+		}
+		defer atomic.AddInt32(&concurrent, -1)
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithEvery(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddJob("slow", func(context.Context) error { return nil }, WithEvery(time.Second)); err == nil {
+		t.Fatal("expected duplicate job name to be rejected")
+	}
+
+	if err := s.Start(nil, nopLogger{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not cancel the running job")
+	}
+
+	if runs != 1 || maxConcurrent != 1 {
+		t.Fatalf("runs = %d, max concurrent = %d, want 1 and 1", runs, maxConcurrent)
+	}
+}
+
+func TestSchedulerRecoversPanicAndAppliesTimeout(t *testing.T) {
+	s := NewScheduler()
+
+	var panics, timeouts int32
+	err := s.AddJob("panic", func(context.Context) error {
+		atomic.AddInt32(&panics, 1)
+		panic("boom")
+	}, WithEvery(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.AddJob("timeout", func(ctx context.Context) error {
+		<-ctx.Done()
+		if ctx.Err() == context.DeadlineExceeded {
+			atomic.AddInt32(&timeouts, 1)
+		}
+		return ctx.Err()
+	}, WithEvery(5*time.Millisecond), WithJobTimeout(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Start(nil, nopLogger{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	s.Stop()
+
+	if atomic.LoadInt32(&panics) < 2 {
+		t.Fatalf("panicking job ran %d times, want the scheduler to keep running it", panics)
+	}
+	if atomic.LoadInt32(&timeouts) == 0 {
+		t.Fatal("job timeout was not applied")
+	}
+}
+
+func TestSchedulerJobsReturnsCopies(t *testing.T) {
+	s := NewScheduler()
+	if err := s.AddJob("cleanup", func(context.Context) error { return nil }, WithEvery(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 1 || jobs[0].Name != "cleanup" || jobs[0].Every != time.Minute {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+	jobs[0].Every = time.Second
+	jobs[0].Disabled = true
+	if j := s.Jobs()[0]; j.Every != time.Minute || j.Disabled {
+		t.Fatalf("modifying the returned job changed the scheduler: %+v", j)
+	}
+}
@@ -0,0 +1,336 @@
+package boot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// streamConfig 服务端流式 RPC 的 WebSocket 及 Server-Sent Events 桥接配置
+//
+//	http:
+//	  stream:
+//	    routes: ["/v1/dashboard/*", "/v1/chat"]
+//	    heartbeat: 30s
+//	    write_timeout: 10s
+//
+// routes 中的路径以 /* 结尾时匹配其下的所有路径
+// 桥接的请求不受 timeout.default 的限制，对应的 gRPC 方法需要在 timeout.methods 中单独设置为 0s 或足够长的时间
+type streamConfig struct {
+	Routes       []string      `mapstructure:"routes"`
+	Heartbeat    time.Duration `mapstructure:"heartbeat" default:"30s" validate:"gt=0"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout" default:"10s" validate:"gt=0"`
+}
+
+const (
+	defaultStreamHeartbeat    = 30 * time.Second
+	defaultStreamWriteTimeout = 10 * time.Second
+)
+
+// WithStreamRoutes 设置需要桥接为 WebSocket 或 Server-Sent Events 的 Gateway 路由，可以被 http.stream.routes 配置覆盖
+func WithStreamRoutes(routes ...string) GRPCAppOption {
+	return func(g *GRPCApplication) {
+		g.streamRoutes = routes
+	}
+}
+
+// streamBridge 将 gRPC-Gateway 以换行分隔 JSON 输出的流式响应桥接为浏览器更易使用的协议
+// 1. WebSocket 升级请求：客户端发送的每条消息作为一行请求体写入 Gateway（支持双向流），每行响应作为一条消息发送
+// 2. Accept 为 text/event-stream 的请求：每行响应作为一个 SSE 事件发送
+// 浏览器断开连接时会取消请求上下文，由 Gateway 取消对应的 gRPC 流；其他请求交由 next 处理
+type streamBridge struct {
+	app    *GRPCApplication
+	stream http.Handler
+	next   http.Handler
+
+	routes       []string
+	heartbeat    time.Duration
+	writeTimeout time.Duration
+	upgrader     websocket.Upgrader
+}
+
+// streamHandler 为 stream 中配置的流式路由挂载 WebSocket 及 SSE 桥接，未配置任何路由时直接返回 next
+func (app *GRPCApplication) streamHandler(stream, next http.Handler) http.Handler {
+	cfg := streamConfig{Routes: app.streamRoutes}
+	if err := app.Config.Bind("http.stream", &cfg); err != nil {
+		app.Log.Error("http.stream 配置格式错误 err:", err)
+		cfg.Heartbeat, cfg.WriteTimeout = defaultStreamHeartbeat, defaultStreamWriteTimeout
+	}
+	if len(cfg.Routes) == 0 {
+		return next
+	}
+
+	b := &streamBridge{
+		app:          app,
+		stream:       stream,
+		next:         next,
+		routes:       cfg.Routes,
+		heartbeat:    cfg.Heartbeat,
+		writeTimeout: cfg.WriteTimeout,
+	}
+	// 未明确列出允许的来源时保留 gorilla/websocket 默认的同源检查，避免跨站劫持携带凭证的 WebSocket 连接
+	if origins := explicitOrigins(app.corsConfig().AllowOrigins); len(origins) > 0 {
+		b.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || sameOrigin(r, origin) || allowOrigin(origins, origin) != ""
+		}
+	}
+	return b
+}
+
+// explicitOrigins 返回 http.cors.allow_origins 中明确列出的来源，忽略 *
+func explicitOrigins(origins []string) []string {
+	var result []string
+	for _, o := range origins {
+		if o != "*" {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// sameOrigin 判断请求来源与请求的 Host 是否一致，与 gorilla/websocket 默认的检查规则相同
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func (b *streamBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case !b.match(r.URL.Path):
+		b.next.ServeHTTP(w, r)
+	case websocket.IsWebSocketUpgrade(r):
+		b.serveWebSocket(w, r)
+	case strings.Contains(r.Header.Get("Accept"), "text/event-stream"):
+		b.serveSSE(w, r)
+	default:
+		b.next.ServeHTTP(w, r)
+	}
+}
+
+func (b *streamBridge) match(path string) bool {
+	for _, route := range b.routes {
+		if strings.HasSuffix(route, "/*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(route, "*")) {
+				return true
+			}
+		} else if path == route {
+			return true
+		}
+	}
+	return false
+}
+
+// serveWebSocket 将 WebSocket 连接桥接到 Gateway 的流式接口
+// 浏览器无法在 WebSocket 握手时指定请求方法，可以通过 ?method=POST 指定转发给 Gateway 时使用的方法
+func (b *streamBridge) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := b.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		b.app.Log.Warn("WebSocket 升级失败:", r.URL.Path, err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	body, bodyWriter := io.Pipe()
+	req := newStreamRequest(ctx, r, body)
+	if m := r.URL.Query().Get("method"); m != "" {
+		req.Method = strings.ToUpper(m)
+	}
+
+	// 客户端发送的消息逐行写入请求体，连接断开时取消请求
+	go func() {
+		defer cancel()
+		for {
+			_, p, err := conn.ReadMessage()
+			if err != nil {
+				bodyWriter.CloseWithError(err)
+				return
+			}
+			p = append(bytes.TrimRight(p, "\n"), '\n')
+			if _, err := bodyWriter.Write(p); err != nil {
+				return
+			}
+		}
+	}()
+
+	lines := b.serveStream(ctx, req)
+	ticker := time.NewTicker(b.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(b.writeTimeout))
+				return
+			}
+			if line.status != 0 {
+				// Gateway 返回错误状态码时以 4000 + 状态码作为关闭码，响应内容作为关闭原因
+				msg := websocket.FormatCloseMessage(websocketCloseCode(line.status), closeReason(line.data))
+				conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(b.writeTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(b.writeTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, line.data); err != nil {
+				b.app.Log.Warn("WebSocket 消息发送失败:", r.URL.Path, err)
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(b.writeTimeout)); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// serveSSE 将 Gateway 的流式接口以 Server-Sent Events 的形式返回，并定时发送注释行作为心跳
+func (b *streamBridge) serveSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		b.next.ServeHTTP(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	lines := b.serveStream(ctx, newStreamRequest(ctx, r, http.NoBody))
+	ticker := time.NewTicker(b.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if line.status != 0 {
+				// 响应头已经发送，Gateway 的错误以 error 事件发送后结束
+				w.Write(append(append([]byte("event: error\ndata: "), line.data...), '\n', '\n'))
+				flusher.Flush()
+				return
+			}
+			_, err = w.Write(append(append([]byte("data: "), line.data...), '\n', '\n'))
+		case <-ticker.C:
+			_, err = io.WriteString(w, ": ping\n\n")
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// streamLine Gateway 流式响应中的一行，status 不为 0 时为 Gateway 返回的非 2xx 状态码，data 为错误内容
+type streamLine struct {
+	data   []byte
+	status int
+}
+
+// serveStream 在后台执行 Gateway 请求，并将响应中的每一行（即每一条流式消息）依次发送到返回的 channel
+// channel 无缓冲，下游发送缓慢时会逐级阻塞到 gRPC 流的接收，从而形成背压；Gateway 请求结束或 ctx 取消时 channel 关闭
+func (b *streamBridge) serveStream(ctx context.Context, r *http.Request) <-chan streamLine {
+	pr, pw := io.Pipe()
+	rw := &pipeResponseWriter{header: make(http.Header), w: pw}
+	go func() {
+		b.stream.ServeHTTP(rw, r)
+		pw.Close()
+	}()
+
+	lines := make(chan streamLine)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(pr)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				msg := streamLine{data: line}
+				if status := rw.status(); status >= http.StatusMultipleChoices {
+					msg.status = status
+				}
+				select {
+				case lines <- msg:
+				case <-ctx.Done():
+					pr.CloseWithError(ctx.Err())
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return lines
+}
+
+// newStreamRequest 基于原始请求创建转发给 Gateway 的请求，去掉 WebSocket 握手相关的请求头
+func newStreamRequest(ctx context.Context, r *http.Request, body io.ReadCloser) *http.Request {
+	req := r.Clone(ctx)
+	req.Body = body
+	req.ContentLength = -1
+	for _, h := range []string{"Connection", "Upgrade", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions", "Sec-Websocket-Protocol"} {
+		req.Header.Del(h)
+	}
+	if body != http.NoBody {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// pipeResponseWriter 将 Gateway 的响应内容写入管道，响应头不会发送给客户端，状态码由桥接转换为错误事件或关闭帧
+type pipeResponseWriter struct {
+	header http.Header
+	w      *io.PipeWriter
+	code   int32
+}
+
+func (p *pipeResponseWriter) Header() http.Header         { return p.header }
+func (p *pipeResponseWriter) Write(b []byte) (int, error) { return p.w.Write(b) }
+func (p *pipeResponseWriter) Flush()                      {}
+
+func (p *pipeResponseWriter) WriteHeader(code int) {
+	atomic.CompareAndSwapInt32(&p.code, 0, int32(code))
+}
+
+func (p *pipeResponseWriter) status() int { return int(atomic.LoadInt32(&p.code)) }
+
+// websocketCloseCode 将 HTTP 状态码转换为 4000-4999 范围内由应用定义的 WebSocket 关闭码
+func websocketCloseCode(status int) int {
+	if status < 100 || status > 999 {
+		return websocket.CloseInternalServerErr
+	}
+	return 4000 + status
+}
+
+// closeReason WebSocket 关闭帧的原因最多 123 字节
+func closeReason(data []byte) string {
+	const max = 123
+	if len(data) <= max {
+		return string(data)
+	}
+	return string(data[:max])
+}
@@ -0,0 +1,180 @@
+package boot
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/liuyuanxiang/go-hulc/config"
+)
+
+// newStreamTestServer 模拟 gRPC-Gateway 的流式输出：每收到一行请求体或每隔一段时间输出一行 JSON
+func newStreamTestServer(t *testing.T, cancelled chan<- struct{}) *httptest.Server {
+	t.Helper()
+
+	app := &GRPCApplication{
+		Application: Application{
+			Log: nopLogger{},
+			Config: config.NewConfigFromMap(map[string]interface{}{
+				"http": map[string]interface{}{
+					"stream": map[string]interface{}{"routes": []interface{}{"/v1/stream/*"}, "heartbeat": "20ms"},
+				},
+			}),
+		},
+		GatewayServeMux: NewGateway(),
+		isOpenGateway:   true,
+	}
+	app.GatewayServeMux.HandlePath(http.MethodGet, "/v1/stream/ticks", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		for i := 0; ; i++ {
+			select {
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+			fmt.Fprintf(w, "{\"result\":{\"tick\":%d}}\n", i)
+			w.(http.Flusher).Flush()
+		}
+	})
+	app.GatewayServeMux.HandlePath(http.MethodPost, "/v1/stream/echo", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			fmt.Fprintf(w, "{\"result\":%s}\n", scanner.Text())
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	app.GatewayServeMux.HandlePath(http.MethodGet, "/v1/stream/denied", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"code":7,"message":"denied"}`)
+	})
+
+	srv := httptest.NewServer(app.HTTPHandler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStreamBridgeSSE(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	srv := newStreamTestServer(t, cancelled)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/stream/ticks", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var events, pings int
+	for events < 3 || pings < 1 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case strings.HasPrefix(line, "data: {\"result\":{\"tick\":"):
+			events++
+		case line == ": ping\n":
+			pings++
+		}
+	}
+	resp.Body.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("stream was not cancelled after the client disconnected")
+	}
+}
+
+func TestStreamBridgeWebSocket(t *testing.T) {
+	srv := newStreamTestServer(t, make(chan struct{}, 1))
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/v1/stream/echo?method=post"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, msg := range []string{`{"n":1}`, `{"n":2}`} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		_, p, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"result":` + msg + `}`; string(p) != want {
+			t.Fatalf("message = %s, want %s", p, want)
+		}
+	}
+}
+
+func TestStreamBridgePassesThroughOtherRequests(t *testing.T) {
+	srv := newStreamTestServer(t, make(chan struct{}, 1))
+
+	resp, err := http.Post(srv.URL+"/v1/stream/echo", "application/json", strings.NewReader("{\"n\":1}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "{\"result\":{\"n\":1}}\n" {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestStreamBridgeForwardsErrorStatus(t *testing.T) {
+	srv := newStreamTestServer(t, make(chan struct{}, 1))
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/stream/denied", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "event: error\ndata: {\"code\":7") {
+		t.Fatalf("SSE body = %q, want an error event", body)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/stream/denied", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _, err = conn.ReadMessage()
+	if ce, ok := err.(*websocket.CloseError); !ok || ce.Code != 4000+http.StatusForbidden {
+		t.Fatalf("read err = %v, want close code %d", err, 4000+http.StatusForbidden)
+	}
+}
+
+func TestStreamBridgeRejectsCrossOriginWebSocket(t *testing.T) {
+	srv := newStreamTestServer(t, make(chan struct{}, 1))
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/v1/stream/echo?method=post"
+
+	header := http.Header{"Origin": {"https://evil.example"}}
+	if conn, _, err := websocket.DefaultDialer.Dial(url, header); err == nil {
+		conn.Close()
+		t.Fatal("cross-origin WebSocket handshake should be rejected by default")
+	}
+
+	header = http.Header{"Origin": {srv.URL}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("same-origin WebSocket handshake failed: %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,212 @@
+package boot
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liuyuanxiang/go-hulc/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// timeoutPolicy 请求超时策略，由配置文件中的 timeout 配置生成
+//
+//	timeout:
+//	  default: 5s
+//	  methods:
+//	    - method: /helloworld.Greeter/SayHello
+//	      timeout: 30s
+//	    - method: /helloworld.Greeter/*
+//	      timeout: 10s
+//	    - method: GET /v1/users/:id
+//	      timeout: 2s
+//
+// method 可以是 gRPC 的完整方法名、以 /* 结尾的服务名，或 Gin 路由的 "METHOD 路径"
+type timeoutPolicy struct {
+	def     time.Duration
+	methods map[string]time.Duration
+}
+
+type timeoutConfig struct {
+	Default time.Duration `mapstructure:"default" validate:"gte=0"`
+	Methods []struct {
+		Method  string        `mapstructure:"method" validate:"required"`
+		Timeout time.Duration `mapstructure:"timeout" validate:"gte=0"`
+	} `mapstructure:"methods" validate:"dive"`
+}
+
+// loadTimeoutPolicy 根据配置生成超时策略，未配置任何超时时返回 nil
+func loadTimeoutPolicy(c *config.Config) (*timeoutPolicy, error) {
+	if c == nil || c.Get("timeout") == nil {
+		return nil, nil
+	}
+
+	var tc timeoutConfig
+	if err := c.Bind("timeout", &tc); err != nil {
+		return nil, err
+	}
+
+	p := &timeoutPolicy{def: tc.Default, methods: make(map[string]time.Duration)}
+	for _, m := range tc.Methods {
+		p.methods[m.Method] = m.Timeout
+	}
+	return p, nil
+}
+
+// lookup 返回指定方法的超时时间，依次匹配完整方法名、服务名及默认值，返回 0 表示不限制
+func (p *timeoutPolicy) lookup(method string) time.Duration {
+	if p == nil {
+		return 0
+	}
+	if d, ok := p.methods[method]; ok {
+		return d
+	}
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		if d, ok := p.methods[method[:i]+"/*"]; ok {
+			return d
+		}
+	}
+	return p.def
+}
+
+// max 返回策略中最长的超时时间，任意方法不限制超时时返回 0
+func (p *timeoutPolicy) max() time.Duration {
+	if p == nil || p.def <= 0 {
+		return 0
+	}
+	max := p.def
+	for _, d := range p.methods {
+		if d <= 0 {
+			return 0
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// timeoutUnaryInterceptor 为 gRPC 一元请求的上下文设置超时时间
+// context.WithTimeout 会保留客户端通过 grpc-timeout 传递的更短的截止时间
+func (app *GRPCApplication) timeoutUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	d := app.timeouts.lookup(info.FullMethod)
+	if d <= 0 {
+		return handler(ctx, req)
+	}
+
+	ctx, cancel, own := withTimeout(ctx, d)
+	defer cancel()
+
+	resp, err := handler(ctx, req)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, app.deadlineExceeded(info.FullMethod, d, own)
+	}
+	return resp, err
+}
+
+// timeoutStreamInterceptor 为 gRPC 流式请求的上下文设置超时时间
+func (app *GRPCApplication) timeoutStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	d := app.timeouts.lookup(info.FullMethod)
+	if d <= 0 {
+		return handler(srv, ss)
+	}
+
+	ctx, cancel, own := withTimeout(ss.Context(), d)
+	defer cancel()
+
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	if ctx.Err() == context.DeadlineExceeded {
+		return app.deadlineExceeded(info.FullMethod, d, own)
+	}
+	return err
+}
+
+// deadlineExceeded 记录请求超时日志，并统一返回 codes.DeadlineExceeded 错误
+func (app *GRPCApplication) deadlineExceeded(method string, d time.Duration, own bool) error {
+	app.timedOut("gRPC", method, d, own)
+	return status.Errorf(codes.DeadlineExceeded, "请求处理超时: %s", method)
+}
+
+// withTimeout 为请求上下文设置超时时间，ctx 中已有更早的截止时间时保留该截止时间
+// own 表示生效的是配置的超时时间，为 false 时说明客户端传递的截止时间会先到达
+func withTimeout(ctx context.Context, d time.Duration) (_ context.Context, _ context.CancelFunc, own bool) {
+	deadline, ok := ctx.Deadline()
+	own = !ok || time.Now().Add(d).Before(deadline)
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, own
+}
+
+// timedOut 记录请求超时日志，配置的超时时间生效时计入 TimeoutCount
+func (app *Application) timedOut(kind, method string, d time.Duration, own bool) {
+	if !own {
+		app.Log.Warn(kind, "请求处理超时:", method, "客户端截止时间先于配置的超时时间", d, "到达")
+		return
+	}
+	atomic.AddUint64(&app.timeoutCount, 1)
+	app.Log.Warn(kind, "请求处理超时:", method, "超时时间:", d)
+}
+
+// TimeoutCount 返回应用启动以来因配置的超时时间到达而结束的请求数量，包括 gRPC、HTTP 接口服务及 Gin 路由
+// 客户端传递的截止时间先到达的请求只记录日志，不计入该数量
+func (app *Application) TimeoutCount() uint64 { return atomic.LoadUint64(&app.timeoutCount) }
+
+// timeoutHandler 为 HTTP 接口服务的请求设置超时时间
+// 由于路由匹配前无法得知对应的 gRPC 方法，这里只使用策略中最长的超时时间兜底，
+// 请求上下文的截止时间会由 gRPC 客户端转换为 grpc-timeout 传递给 gRPC 服务，再由服务端按方法收紧；
+// 客户端通过 Grpc-Timeout 请求头传递的更短的超时时间同样会被 gRPC-Gateway 遵守
+func (app *Application) timeoutHandler(h http.Handler) http.Handler {
+	d := app.timeouts.max()
+	if d <= 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel, own := withTimeout(r.Context(), d)
+		defer cancel()
+		h.ServeHTTP(w, r.WithContext(ctx))
+		if ctx.Err() == context.DeadlineExceeded {
+			app.timedOut("HTTP", r.Method+" "+r.URL.Path, d, own)
+		}
+	})
+}
+
+// TimeoutMiddleware 返回按配置对 Gin 路由进行超时控制的中间件
+// 路由的超时时间通过 timeout.methods 中 "METHOD 路径" 形式的配置覆盖，路径与注册路由时一致
+func (app *Application) TimeoutMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		d := app.timeouts.lookup(route)
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel, own := withTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if ctx.Err() == context.DeadlineExceeded {
+			app.timedOut("HTTP", route, d, own)
+			if !c.Writer.Written() {
+				c.AbortWithStatusJSON(http.StatusGatewayTimeout, &httpErrorResponse{
+					ErrCode: 10000,
+					Message: "请求处理超时",
+				})
+			}
+		}
+	}
+}
+
+// serverStream 可以替换上下文的 grpc.ServerStream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
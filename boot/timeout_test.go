@@ -0,0 +1,125 @@
+package boot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liuyuanxiang/go-hulc/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTimeoutTestApp(t *testing.T) *GRPCApplication {
+	t.Helper()
+
+	app := &GRPCApplication{Application: Application{
+		Log: nopLogger{},
+		Config: config.NewConfigFromMap(map[string]interface{}{
+			"timeout": map[string]interface{}{
+				"default": "20ms",
+				"methods": []interface{}{
+					map[string]interface{}{"method": "/test.Svc/Slow", "timeout": "1s"},
+					map[string]interface{}{"method": "/other.Svc/*", "timeout": "50ms"},
+				},
+			},
+		}),
+	}}
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestTimeoutPolicyLookup(t *testing.T) {
+	p := newTimeoutTestApp(t).timeouts
+
+	cases := map[string]time.Duration{
+		"/test.Svc/Slow":  time.Second,
+		"/test.Svc/Fast":  20 * time.Millisecond,
+		"/other.Svc/Any":  50 * time.Millisecond,
+		"GET /v1/unknown": 20 * time.Millisecond,
+	}
+	for method, want := range cases {
+		if got := p.lookup(method); got != want {
+			t.Errorf("lookup(%q) = %v, want %v", method, got, want)
+		}
+	}
+	if p.max() != time.Second {
+		t.Errorf("max() = %v, want 1s", p.max())
+	}
+}
+
+func TestTimeoutUnaryInterceptor(t *testing.T) {
+	app := newTimeoutTestApp(t)
+
+	slow := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	start := time.Now()
+	_, err := app.timeoutUnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Svc/Fast"}, slow)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("handler ran for %v, default timeout was not applied", elapsed)
+	}
+
+	// 客户端传递的更短的截止时间优先生效
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	_, err = app.timeoutUnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Svc/Slow"}, slow)
+	if status.Code(err) != codes.DeadlineExceeded || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("client deadline was not respected, err = %v", err)
+	}
+	if app.TimeoutCount() != 1 {
+		t.Fatalf("timeouts = %d, want only the configured timeout to be counted", app.TimeoutCount())
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	app := newTimeoutTestApp(t)
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(app.TimeoutMiddleware())
+	e.GET("/slow", func(c *gin.Context) { <-c.Request.Context().Done() })
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if rec.Code != http.StatusGatewayTimeout || app.TimeoutCount() != 1 {
+		t.Fatalf("status = %d, timeouts = %d, want 504 and 1", rec.Code, app.TimeoutCount())
+	}
+
+	// 客户端的截止时间先到达时不计入超时数量
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx))
+	if rec.Code != http.StatusGatewayTimeout || app.TimeoutCount() != 1 {
+		t.Fatalf("status = %d, timeouts = %d, want 504 and 1", rec.Code, app.TimeoutCount())
+	}
+}
+
+func TestTimeoutHandler(t *testing.T) {
+	app := newTimeoutTestApp(t)
+	app.timeouts = &timeoutPolicy{def: 20 * time.Millisecond}
+
+	var deadline bool
+	h := app.timeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, deadline = r.Context().Deadline()
+		<-r.Context().Done()
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/slow", nil))
+	if !deadline || rec.Code != http.StatusGatewayTimeout || app.TimeoutCount() != 1 {
+		t.Fatalf("deadline = %v, status = %d, timeouts = %d", deadline, rec.Code, app.TimeoutCount())
+	}
+}
@@ -0,0 +1,109 @@
+package boot
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// protoc-gen-validate 及 buf validate 生成代码中提供的校验方法
+// 同时存在时优先使用 ValidateAll，可以一次返回所有字段的校验错误
+type protoValidatorAll interface {
+	ValidateAll() error
+}
+
+type protoValidator interface {
+	Validate() error
+}
+
+// 生成代码中字段校验错误及其聚合错误的方法集合
+type fieldValidationError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+type multiError interface {
+	AllErrors() []error
+}
+
+// validateUnaryInterceptor 在执行 gRPC 一元请求前对请求消息进行校验
+func validateUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := validateMessage(req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// validateStreamInterceptor 对 gRPC 流式请求中客户端发送的每一条消息进行校验
+func validateStreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validateServerStream{ServerStream: ss})
+}
+
+type validateServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validateServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateMessage(m)
+}
+
+// validateMessage 调用消息上的校验方法，并将校验错误转换为携带 errdetails.BadRequest 的 codes.InvalidArgument 错误
+func validateMessage(m interface{}) error {
+	var err error
+	switch v := m.(type) {
+	case protoValidatorAll:
+		err = v.ValidateAll()
+	case protoValidator:
+		err = v.Validate()
+	default:
+		return nil
+	}
+	if err == nil {
+		return nil
+	}
+
+	br := &errdetails.BadRequest{FieldViolations: fieldViolations("", err)}
+	s, detailErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(br)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.Err()
+}
+
+// fieldViolations 将校验错误展开为字段错误清单，嵌套消息的字段名使用 . 连接
+func fieldViolations(prefix string, err error) []*errdetails.BadRequest_FieldViolation {
+	if me, ok := err.(multiError); ok {
+		var violations []*errdetails.BadRequest_FieldViolation
+		for _, e := range me.AllErrors() {
+			violations = append(violations, fieldViolations(prefix, e)...)
+		}
+		return violations
+	}
+
+	fe, ok := err.(fieldValidationError)
+	if !ok {
+		return []*errdetails.BadRequest_FieldViolation{{Field: prefix, Description: err.Error()}}
+	}
+
+	field := fe.Field()
+	if prefix != "" {
+		field = prefix + "." + field
+	}
+	// 嵌套消息校验失败时，具体的字段错误保存在 Cause 中
+	if cause := fe.Cause(); cause != nil {
+		if _, nested := cause.(fieldValidationError); nested {
+			return fieldViolations(field, cause)
+		}
+		if _, nested := cause.(multiError); nested {
+			return fieldViolations(field, cause)
+		}
+	}
+	return []*errdetails.BadRequest_FieldViolation{{Field: field, Description: fe.Reason()}}
+}
@@ -0,0 +1,84 @@
+package boot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/liuyuanxiang/go-hulc/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 以下类型模拟 protoc-gen-validate 生成的校验错误
+type testFieldError struct {
+	field, reason string
+	cause         error
+}
+
+func (e testFieldError) Field() string  { return e.field }
+func (e testFieldError) Reason() string { return e.reason }
+func (e testFieldError) Cause() error   { return e.cause }
+func (e testFieldError) Error() string  { return "invalid " + e.field + ": " + e.reason }
+
+type testMultiError []error
+
+func (m testMultiError) Error() string      { return "multiple errors" }
+func (m testMultiError) AllErrors() []error { return m }
+
+type testRequest struct{ err error }
+
+func (r *testRequest) ValidateAll() error { return r.err }
+
+func TestValidateUnaryInterceptor(t *testing.T) {
+	req := &testRequest{err: testMultiError{
+		testFieldError{field: "Name", reason: "value length must be at least 1 runes"},
+		testFieldError{field: "Address", reason: "embedded message failed validation", cause: testFieldError{field: "City", reason: "value is required"}},
+	}}
+
+	called := false
+	_, err := validateUnaryInterceptor(context.Background(), req, &grpc.UnaryServerInfo{}, func(context.Context, interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	if called {
+		t.Fatal("handler should not be called for an invalid request")
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("err = %v, want InvalidArgument", err)
+	}
+
+	rec := httptest.NewRecorder()
+	httpErrorHandler(func() logger.LogInterface { return nopLogger{} })(context.Background(), nil, &runtime.JSONPb{}, rec, httptest.NewRequest(http.MethodGet, "/", nil), err)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+
+	var resp struct {
+		Data []fieldError `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []fieldError{
+		{Field: "Name", Message: "value length must be at least 1 runes"},
+		{Field: "Address.City", Message: "value is required"},
+	}
+	if len(resp.Data) != len(want) || resp.Data[0] != want[0] || resp.Data[1] != want[1] {
+		t.Fatalf("data = %+v, want %+v", resp.Data, want)
+	}
+}
+
+func TestValidateMessageIgnoresPlainMessages(t *testing.T) {
+	if err := validateMessage(struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateMessage(&testRequest{err: errors.New("bad")}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("err = %v, want InvalidArgument", err)
+	}
+}
@@ -110,3 +110,31 @@ func Tree(path string, dir string) {
 		return nil
 	})
 }
+
+// EnsureGitignore appends the patterns missing from the .gitignore in dir,
+// creating the file if it does not exist.
+func EnsureGitignore(dir string, patterns ...string) error {
+	file := path.Join(dir, ".gitignore")
+	buf, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(buf), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	var missing []string
+	for _, p := range patterns {
+		if !existing[p] {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if len(buf) > 0 && !bytes.HasSuffix(buf, []byte("\n")) {
+		buf = append(buf, '\n')
+	}
+	buf = append(buf, strings.Join(missing, "\n")+"\n"...)
+	return ioutil.WriteFile(file, buf, 0644)
+}
@@ -0,0 +1,32 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestEnsureGitignore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hulc-gitignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, ".gitignore")
+	if err := ioutil.WriteFile(file, []byte("bin/\n*.local.yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := EnsureGitignore(dir, "*.local.yaml", "runtime/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "bin/\n*.local.yaml\nruntime/\n"; string(buf) != want {
+		t.Fatalf(".gitignore = %q, want %q", buf, want)
+	}
+}
@@ -43,6 +43,10 @@ func (p *Project) New(ctx context.Context, dir string, layout string, branch str
 		path.Join(to, "cmd", "server"),
 		path.Join(to, "cmd", p.Name),
 	)
+	// app.local.yaml is a developer-specific config overlay and must not be committed.
+	if err := base.EnsureGitignore(to, "*.local.yaml"); err != nil {
+		return err
+	}
 	base.Tree(to, dir)
 
 	fmt.Printf("\n🍺 Project creation succeeded %s\n", color.GreenString(p.Name))
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/liuyuanxiang/go-hulc/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ByteSize 字节大小，绑定时支持 4194304、512KB、16MB、1G 等写法
+type ByteSize int64
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+	timeType     = reflect.TypeOf(time.Time{})
+
+	bindValidator = newBindValidator()
+)
+
+func newBindValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("validate")
+	// 校验错误中使用配置项的名称，而不是结构体的字段名
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		return fieldKey(f)
+	})
+	return v
+}
+
+// BindError 配置绑定失败时返回的错误，包含所有配置项的问题
+type BindError struct {
+	Key      string
+	Problems []string
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("配置 %s 绑定失败: %s", e.Key, strings.Join(e.Problems, "; "))
+}
+
+// Bind 将 key 下的配置内容解析到 out 指向的结构体中，key 为空时解析全部配置
+//
+//	type MongoConfig struct {
+//		Host    string          `mapstructure:"host" default:"127.0.0.1" validate:"required"`
+//		Port    int             `mapstructure:"port" default:"27017" validate:"min=1,max=65535"`
+//		Timeout time.Duration   `mapstructure:"timeout" default:"5s"`
+//		MaxSize config.ByteSize `mapstructure:"max_size" default:"16MB"`
+//	}
+//
+// 1. 字段名默认为小写的结构体字段名，可以通过 mapstructure 标签指定
+// 2. 配置中不存在的字段使用 default 标签中的值，切片的默认值使用 , 分隔
+// 3. time.Duration 字段支持 5s、1m30s 等字符串，不带单位的整数按秒解析，ByteSize 字段支持带单位的字节大小
+// 4. 解析完成后按 validate 标签进行校验，规则与 go-playground/validator 一致
+// out 中已有的字段值在配置中不存在时保留，可以作为代码中设置的默认值
+// 所有配置项的类型及校验问题会汇总到一个 *BindError 中返回
+func (c *Config) Bind(key string, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config.Bind: out 需要是结构体指针，实际为 %T", out)
+	}
+	if !c.loaded() {
+		return ErrNotLoaded
+	}
+
+	input := c.current().data
+	if key != "" {
+		v := c.Get(key)
+		m, ok := toStringMap(v)
+		if v != nil && !ok {
+			// 如 mongo: localhost，整段配置写成了单个值
+			return &BindError{Key: key, Problems: []string{fmt.Sprintf("%s: 需要配置段，实际为 %T 类型的取值", key, v)}}
+		}
+		input = m
+	}
+	input = normalize(input)
+	applyDefaults(rv.Elem().Type(), input)
+
+	bindErr := &BindError{Key: key}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(durationHook, byteSizeHook, coerceHook),
+		WeaklyTypedInput: true,
+		// 配置中的切片及 map 整体替换 out 中已有的值，而不是逐个元素覆盖
+		ZeroFields: true,
+		Result:     out,
+	})
+	if err != nil {
+		return err
+	}
+	// 类型错误的字段不再重复报告校验问题
+	failed := make(map[string]bool)
+	if err := decoder.Decode(input); err != nil {
+		if me, ok := err.(*mapstructure.Error); ok {
+			for _, e := range me.Errors {
+				field, problem := qualify(key, e)
+				failed[field] = true
+				if full := strings.TrimPrefix(key+"."+field, "."); field != "" && c.IsSensitive(full) {
+					// mapstructure 的错误信息中包含取值
+					problem = full + ": 类型错误"
+				}
+				bindErr.Problems = append(bindErr.Problems, problem)
+			}
+		} else {
+			bindErr.Problems = append(bindErr.Problems, err.Error())
+		}
+	}
+
+	if err := bindValidator.Struct(out); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			for _, fe := range ve {
+				if failed[fieldPath(fe)] {
+					continue
+				}
+				bindErr.Problems = append(bindErr.Problems, validationProblem(key, fe, c.IsSensitive))
+			}
+		} else {
+			bindErr.Problems = append(bindErr.Problems, err.Error())
+		}
+	}
+
+	if len(bindErr.Problems) > 0 {
+		return bindErr
+	}
+	return nil
+}
+
+// fieldKey 返回结构体字段对应的配置项名称
+func fieldKey(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return strings.ToLower(name)
+}
+
+func isSquash(f reflect.StructField) bool {
+	for _, opt := range strings.Split(f.Tag.Get("mapstructure"), ",")[1:] {
+		if opt == "squash" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDefaults 将结构体 default 标签中的默认值写入缺失的配置项，嵌套的结构体同样处理
+func applyDefaults(t reflect.Type, m map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != durationType && ft != timeType {
+			if f.Anonymous && isSquash(f) {
+				applyDefaults(ft, m)
+				continue
+			}
+			key := fieldKey(f)
+			if key == "" {
+				continue
+			}
+			sub, ok := toStringMap(m[key])
+			if !ok && m[key] != nil {
+				continue
+			}
+			if sub == nil {
+				sub = make(map[string]interface{})
+			}
+			applyDefaults(ft, sub)
+			if len(sub) > 0 {
+				m[key] = sub
+			}
+			continue
+		}
+
+		def, ok := f.Tag.Lookup("default")
+		key := fieldKey(f)
+		if !ok || key == "" {
+			continue
+		}
+		if _, exists := m[key]; exists {
+			continue
+		}
+		if ft.Kind() == reflect.Slice {
+			items := []interface{}{}
+			for _, item := range strings.Split(def, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			m[key] = items
+			continue
+		}
+		m[key] = def
+	}
+}
+
+// durationHook 将 5s、1m30s 等字符串解析为 time.Duration，不带单位的整数按秒解析，参见 toDurationE
+func durationHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != durationType {
+		return data, nil
+	}
+	if s, ok := data.(string); ok && s == "" {
+		return time.Duration(0), nil
+	}
+	return toDurationE(data)
+}
+
+// byteSizeHook 将带单位的字节大小解析为 ByteSize
+func byteSizeHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != byteSizeType {
+		return data, nil
+	}
+	if s, ok := data.(string); ok {
+		n, err := util.ParseBytes(s)
+		if err != nil {
+			return nil, err
+		}
+		return ByteSize(n), nil
+	}
+	return data, nil
+}
+
+// decodeField 匹配 mapstructure 错误信息中以单引号标注的字段名
+var decodeField = regexp.MustCompile(`'([^']+)'`)
+
+// qualify 为 mapstructure 的错误信息补充完整的配置项名称，同时返回出错字段相对于 key 的名称
+func qualify(key, msg string) (string, string) {
+	var field string
+	if m := decodeField.FindStringSubmatch(msg); m != nil {
+		field = m[1]
+	}
+	full := field
+	if key != "" {
+		full = strings.TrimSuffix(key+"."+field, ".")
+	}
+	if full == "" {
+		return field, msg
+	}
+	return field, full + ": " + msg
+}
+
+// fieldPath 返回校验错误对应的字段相对于 key 的名称
+func fieldPath(fe validator.FieldError) string {
+	field := fe.Namespace()
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+	return field
+}
+
+// validationProblem 将字段校验错误转换为以完整配置项名称开头的问题描述
+// 敏感配置项的取值不会出现在问题描述中
+func validationProblem(key string, fe validator.FieldError, isSensitive func(string) bool) string {
+	field := fieldPath(fe)
+	if key != "" {
+		field = key + "." + field
+	}
+
+	if strings.HasPrefix(fe.Tag(), "required") {
+		return field + ": 缺少必填配置"
+	}
+	rule := fe.Tag()
+	if fe.Param() != "" {
+		rule += "=" + fe.Param()
+	}
+	if isSensitive(field) {
+		return fmt.Sprintf("%s: 取值不满足校验规则 %s", field, rule)
+	}
+	return fmt.Sprintf("%s: 取值 %v 不满足校验规则 %s", field, fe.Value(), rule)
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testServerConfig struct {
+	Host      string        `mapstructure:"host" default:"127.0.0.1" validate:"required"`
+	Port      int           `mapstructure:"port" validate:"min=1,max=65535"`
+	Timeout   time.Duration `mapstructure:"timeout" default:"5s"`
+	MaxSize   ByteSize      `mapstructure:"max_size" default:"4MB"`
+	Tags      []string      `mapstructure:"tags" default:"a, b"`
+	Keepalive struct {
+		Time time.Duration `mapstructure:"time" default:"2h"`
+	} `mapstructure:"keepalive"`
+}
+
+func TestBind(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"grpc": map[string]interface{}{
+			"port":     9000,
+			"max_size": "16MB",
+		},
+	})
+
+	var cfg testServerConfig
+	if err := c.Bind("grpc", &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Host != "127.0.0.1" || cfg.Port != 9000 || cfg.Timeout != 5*time.Second || cfg.MaxSize != 16<<20 {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	if len(cfg.Tags) != 2 || cfg.Tags[1] != "b" || cfg.Keepalive.Time != 2*time.Hour {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+}
+
+func TestBindFromStrings(t *testing.T) {
+	// 来自环境变量或命令行参数的值均为字符串
+	c := NewConfigFromMap(map[string]interface{}{
+		"grpc": map[string]interface{}{"port": "9100", "timeout": "300ms"},
+	})
+
+	var cfg testServerConfig
+	if err := c.Bind("grpc", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 9100 || cfg.Timeout != 300*time.Millisecond {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+}
+
+func TestBindDurationFromSeconds(t *testing.T) {
+	// 旧配置中不带单位的整数按秒解析，如 YAML 中的 timeout: 300、JSON 中的 300 及环境变量中的 "300"
+	for _, v := range []interface{}{300, float64(300), int64(300), "300"} {
+		c := NewConfigFromMap(map[string]interface{}{
+			"grpc": map[string]interface{}{"port": 9000, "timeout": v},
+		})
+		var cfg testServerConfig
+		if err := c.Bind("grpc", &cfg); err != nil {
+			t.Fatalf("%T: %v", v, err)
+		}
+		if cfg.Timeout != 300*time.Second {
+			t.Fatalf("%T: timeout = %v, want 5m0s", v, cfg.Timeout)
+		}
+		if d, err := c.GetDurationE("grpc.timeout"); err != nil || d != 300*time.Second {
+			t.Fatalf("%T: GetDurationE = %v, %v", v, d, err)
+		}
+	}
+}
+
+func TestBindReportsEveryProblem(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"grpc": map[string]interface{}{
+			"host":     "",
+			"port":     70000,
+			"timeout":  1.5,
+			"max_size": "lots",
+		},
+	})
+
+	var cfg testServerConfig
+	err := c.Bind("grpc", &cfg)
+	be, ok := err.(*BindError)
+	if !ok {
+		t.Fatalf("err = %v, want *BindError", err)
+	}
+
+	msg := be.Error()
+	for _, want := range []string{"grpc.host: 缺少必填配置", "grpc.port: 取值 70000", "timeout", "max_size"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("%q should contain %q", msg, want)
+		}
+	}
+	if len(be.Problems) != 4 {
+		t.Errorf("problems = %v", be.Problems)
+	}
+}
+
+func TestBindRejectsScalarSection(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{"mongo": "localhost"})
+	var cfg testServerConfig
+	err := c.Bind("mongo", &cfg)
+	be, ok := err.(*BindError)
+	if !ok || len(be.Problems) != 1 || !strings.Contains(be.Problems[0], "mongo: 需要配置段") {
+		t.Fatalf("err = %v, want a BindError for the scalar mongo value", err)
+	}
+}
+
+func TestBindKeepsPresetValues(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"http": map[string]interface{}{"methods": []interface{}{"GET"}},
+	})
+
+	cfg := struct {
+		Methods []string `mapstructure:"methods"`
+		Origins []string `mapstructure:"origins"`
+	}{
+		Methods: []string{"POST", "GET", "OPTIONS"},
+		Origins: []string{"*"},
+	}
+	if err := c.Bind("http", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Methods) != 1 || cfg.Methods[0] != "GET" || len(cfg.Origins) != 1 {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+}
+
+func TestBindCoercesLikeGetters(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"app": map[string]interface{}{"port": "8080", "debug": "on", "hosts": "a,b", "workers": 2.5},
+	})
+	var out struct {
+		Port    int      `mapstructure:"port"`
+		Debug   bool     `mapstructure:"debug"`
+		Hosts   []string `mapstructure:"hosts"`
+		Workers int      `mapstructure:"workers"`
+	}
+	err := c.Bind("app", &out)
+	if out.Port != 8080 || !out.Debug || len(out.Hosts) != 2 {
+		t.Fatalf("out = %+v", out)
+	}
+	if err == nil || !strings.Contains(err.Error(), "app.workers") {
+		t.Fatalf("Bind error = %v, want app.workers problem", err)
+	}
+}
+
+func TestBindHidesSensitiveValues(t *testing.T) {
+	var out struct {
+		Password string `mapstructure:"password" validate:"min=12"`
+		Token    int    `mapstructure:"token"`
+	}
+	c := NewConfigFromMap(map[string]interface{}{
+		"db": map[string]interface{}{"password": "hunter2", "token": "tk-abcdef"},
+	})
+	err := c.Bind("db", &out)
+	if err == nil || !strings.Contains(err.Error(), "db.password") || !strings.Contains(err.Error(), "db.token") {
+		t.Fatalf("Bind error = %v, want db.password and db.token problems", err)
+	}
+	if strings.Contains(err.Error(), "hunter2") || strings.Contains(err.Error(), "tk-abcdef") {
+		t.Fatalf("Bind error = %v, leaks a sensitive value", err)
+	}
+
+	if _, err := c.GetIntE("db.token"); err == nil || strings.Contains(err.Error(), "tk-abcdef") {
+		t.Fatalf("GetIntE error = %v, want a type error without the value", err)
+	}
+}
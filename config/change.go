@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChangeFunc 配置项变更时的回调函数，old 与 new 为变更前后的值，配置项被删除或新增时对应的值为 nil
+type ChangeFunc func(old, new interface{})
+
+// ValidateFunc 在重新加载的配置生效前对其进行校验，返回错误时放弃本次变更
+type ValidateFunc func(next *Config) error
+
+type subscription struct {
+	key string
+	fn  ChangeFunc
+}
+
+// listeners 配置变更的监听函数及校验函数
+type listeners struct {
+	lmu        sync.Mutex
+	subs       []subscription
+	validators []ValidateFunc
+	schemas    []schema
+	reloads    []ReloadFunc
+}
+
+// OnChange 注册配置项变更时的监听函数，key 可以是完整的配置项，也可以是其上级，如 mongo 会在任意 mongo.* 变更时触发
+// 一次重新加载中同一个监听函数最多触发一次，old 与 new 为 key 对应的完整内容
+func (c *Config) OnChange(key string, fn ChangeFunc) {
+	c.lmu.Lock()
+	defer c.lmu.Unlock()
+	c.subs = append(c.subs, subscription{key: strings.ToLower(key), fn: fn})
+}
+
+// AddValidator 注册重新加载配置时的校验函数，任意校验函数返回错误时继续使用原有配置
+// 通常在函数中对 next 调用 Bind，以确保组件可以正常使用新的配置
+func (c *Config) AddValidator(fn ValidateFunc) {
+	c.lmu.Lock()
+	defer c.lmu.Unlock()
+	c.validators = append(c.validators, fn)
+}
+
+// Reload 重新读取并合并所有配置文件，通过所有校验函数及 Require 声明的校验后替换当前配置，并通知发生变更的配置项的监听函数
+// 读取或校验失败时返回错误，当前配置保持不变
+func (c *Config) Reload() error {
+	if !c.loaded() {
+		return nil
+	}
+
+	st, err := c.build()
+	if err != nil {
+		return err
+	}
+
+	next := &Config{envPrefix: c.envPrefix, isLoad: true, st: st}
+	c.lmu.Lock()
+	validators := append([]ValidateFunc(nil), c.validators...)
+	c.lmu.Unlock()
+	for _, validate := range validators {
+		if err := validate(next); err != nil {
+			return fmt.Errorf("配置校验失败，继续使用原有配置 err: %v", err)
+		}
+	}
+	if err := c.validateSchemas(next); err != nil {
+		return fmt.Errorf("配置校验失败，继续使用原有配置 err: %v", err)
+	}
+
+	c.swap(st)
+	return nil
+}
+
+// swap 替换当前的配置内容，并通知发生变更的配置项的监听函数及 OnReload 注册的回调函数
+// 配置内容没有变化时不会生成新的版本，但仍会更新配置项的来源
+func (c *Config) swap(st *state) {
+	c.mu.Lock()
+	changed := changedKeys(c.st.data, st.data)
+	if len(changed) == 0 {
+		st.version, st.time = c.st.version, c.st.time
+		c.st = st
+		c.mu.Unlock()
+		return
+	}
+	redact := c.redactPattern()
+	old := c.install(st)
+	c.mu.Unlock()
+
+	c.lmu.Lock()
+	subs := append([]subscription(nil), c.subs...)
+	reloads := append([]ReloadFunc(nil), c.reloads...)
+	c.lmu.Unlock()
+	if len(reloads) > 0 {
+		prev, next := &Snapshot{st: old, redact: redact}, &Snapshot{st: st, redact: redact}
+		changes := Diff(prev, next)
+		for _, fn := range reloads {
+			fn(prev, next, changes)
+		}
+	}
+	for _, sub := range subs {
+		if matchAny(sub.key, changed) {
+			sub.fn(lookup(old.data, sub.key), lookup(st.data, sub.key))
+		}
+	}
+}
+
+// changedKeys 返回两份配置内容之间新增、删除或取值不同的配置项，按名称排序
+func changedKeys(old, new map[string]interface{}) []string {
+	var changed []string
+	seen := make(map[string]bool)
+	for _, key := range keys(old) {
+		seen[key] = true
+		if !reflect.DeepEqual(lookup(old, key), lookup(new, key)) {
+			changed = append(changed, key)
+		}
+	}
+	for _, key := range keys(new) {
+		if !seen[key] {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// matchAny 判断 key 本身或其下级配置项是否在 changed 中
+func matchAny(key string, changed []string) bool {
+	for _, k := range changed {
+		if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
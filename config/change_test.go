@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newReloadConfig(t *testing.T) (*Config, string) {
+	t.Helper()
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", "logger:\n  level: info\nmongo:\n  host: a\n  port: 27017\n")
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	return c, dir
+}
+
+func TestReloadNotifiesChangedKeys(t *testing.T) {
+	c, dir := newReloadConfig(t)
+
+	var level [2]interface{}
+	var mongoCalls, otherCalls int
+	c.OnChange("logger.level", func(old, new interface{}) { level = [2]interface{}{old, new} })
+	c.OnChange("mongo", func(old, new interface{}) { mongoCalls++ })
+	c.OnChange("grpc", func(old, new interface{}) { otherCalls++ })
+
+	writeConfig(t, dir, "app.yaml", "logger:\n  level: debug\nmongo:\n  host: b\n  port: 27018\n")
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if level != [2]interface{}{"info", "debug"} {
+		t.Fatalf("logger.level change = %v", level)
+	}
+	if mongoCalls != 1 || otherCalls != 0 {
+		t.Fatalf("mongo calls = %d, other calls = %d", mongoCalls, otherCalls)
+	}
+	if c.GetString("mongo.host") != "b" {
+		t.Fatalf("mongo.host = %q", c.GetString("mongo.host"))
+	}
+}
+
+func TestReloadKeepsPreviousConfigWhenInvalid(t *testing.T) {
+	c, dir := newReloadConfig(t)
+
+	c.AddValidator(func(next *Config) error {
+		if next.GetString("logger.level") == "verbose" {
+			return errors.New("unknown level")
+		}
+		return nil
+	})
+	called := false
+	c.OnChange("logger.level", func(old, new interface{}) { called = true })
+
+	writeConfig(t, dir, "app.yaml", "logger:\n  level: verbose\n")
+	if err := c.Reload(); err == nil {
+		t.Fatal("Reload should fail validation")
+	}
+	if called || c.GetString("logger.level") != "info" || c.GetString("mongo.host") != "a" {
+		t.Fatalf("config changed after failed validation: called = %v, level = %q", called, c.GetString("logger.level"))
+	}
+
+	// 语法错误的文件同样不会生效
+	writeConfig(t, dir, "app.yaml", "logger: [\n")
+	if err := c.Reload(); err == nil || c.GetString("logger.level") != "info" {
+		t.Fatalf("err = %v, level = %q", err, c.GetString("logger.level"))
+	}
+}
+
+func TestSetNotifiesChange(t *testing.T) {
+	c, _ := newReloadConfig(t)
+
+	var got interface{}
+	c.OnChange("logger.level", func(_, new interface{}) { got = new })
+	c.Set("logger.level", "warn")
+
+	if got != "warn" || c.GetString("logger.level") != "warn" {
+		t.Fatalf("got = %v, level = %q", got, c.GetString("logger.level"))
+	}
+}
+
+func TestWatchReloadsOnFileChange(t *testing.T) {
+	c, dir := newReloadConfig(t)
+
+	changed := make(chan interface{}, 1)
+	c.OnChange("logger.level", func(_, new interface{}) { changed <- new })
+	if err := c.Watch(func(err error) { t.Error(err) }); err != nil {
+		t.Fatal(err)
+	}
+	defer c.StopWatch()
+
+	// 新建的本地配置文件同样会触发重新加载
+	writeConfig(t, dir, "app.local.yaml", "logger:\n  level: error\n")
+
+	select {
+	case v := <-changed:
+		if v != "error" {
+			t.Fatalf("logger.level = %v, want error", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded after the file changed")
+	}
+}
+
+func TestWatchFollowsSymlinkSwap(t *testing.T) {
+	// 模拟 Kubernetes ConfigMap 的挂载方式：app.yaml -> ..data/app.yaml，..data -> 带时间戳的目录
+	dir := tempDir(t)
+	for name, level := range map[string]string{"v1": "info", "v2": "debug"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeConfig(t, filepath.Join(dir, name), "app.yaml", "logger:\n  level: "+level+"\n")
+	}
+	if err := os.Symlink("v1", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "app.yaml"), filepath.Join(dir, "app.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan interface{}, 1)
+	c.OnChange("logger.level", func(_, new interface{}) { changed <- new })
+	if err := c.Watch(func(err error) { t.Error(err) }); err != nil {
+		t.Fatal(err)
+	}
+	defer c.StopWatch()
+
+	if err := os.Symlink("v2", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case v := <-changed:
+		if v != "debug" {
+			t.Fatalf("logger.level = %v, want debug", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded after the ..data symlink was swapped")
+	}
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liuyuanxiang/go-hulc/util"
+	"github.com/spf13/cast"
+)
+
+// 以下转换函数统一了不同来源中同一配置值的类型差异：
+// YAML 中的整数为 int，JSON 中为 float64，TOML 中为 int64，环境变量及 -set 参数中为 string
+// 转换只在不丢失信息时进行，例如 1.5 不会被截断为整数，时间间隔中的整数按秒解析而不会被当作纳秒
+
+// toInt64E 转换为 int64，接受整数、没有小数部分的浮点数及十进制整数字符串
+func toInt64E(v interface{}) (int64, error) {
+	if n, ok := v.(json.Number); ok {
+		v = string(n)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("取值 %v 超出整数范围", v)
+		}
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f > math.MaxInt64 || f < math.MinInt64 {
+			return 0, fmt.Errorf("需要整数，实际为 %v", v)
+		}
+		return int64(f), nil
+	case reflect.String:
+		n, err := strconv.ParseInt(strings.TrimSpace(rv.String()), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("需要整数，实际为 %q", rv.String())
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("需要整数，实际为 %T 类型的 %v", v, v)
+}
+
+// toIntRangeE 转换为整数，并检查是否在 [min, max] 的范围内
+func toIntRangeE(v interface{}, min, max int64) (int64, error) {
+	n, err := toInt64E(v)
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("取值 %d 超出范围 [%d, %d]", n, min, max)
+	}
+	return n, nil
+}
+
+// toFloat64E 转换为 float64，接受所有数字及数字字符串
+func toFloat64E(v interface{}) (float64, error) {
+	if n, ok := v.(json.Number); ok {
+		v = string(n)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil {
+			return 0, fmt.Errorf("需要数字，实际为 %q", rv.String())
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("需要数字，实际为 %T 类型的 %v", v, v)
+}
+
+// toBoolE 转换为 bool，字符串接受 true/false、1/0、yes/no、on/off，与 YAML 的写法保持一致
+func toBoolE(v interface{}) (bool, error) {
+	switch val := v.(type) {
+	case bool:
+		return val, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "true", "t", "1", "yes", "y", "on":
+			return true, nil
+		case "false", "f", "0", "no", "n", "off":
+			return false, nil
+		}
+		return false, fmt.Errorf("需要布尔值，实际为 %q", val)
+	}
+	if n, err := toInt64E(v); err == nil && (n == 0 || n == 1) {
+		return n == 1, nil
+	}
+	return false, fmt.Errorf("需要布尔值，实际为 %T 类型的 %v", v, v)
+}
+
+// toStringE 转换为字符串，接受字符串、数字、布尔值及时间，map 与列表不会被转换
+func toStringE(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case time.Duration:
+		return val.String(), nil
+	case time.Time:
+		return val.Format(time.RFC3339), nil
+	case json.Number:
+		return string(val), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("需要字符串，实际为 %T 类型的 %v", v, v)
+}
+
+// toDurationE 转换为时间间隔，接受 5s、1m30s 形式的字符串
+// 兼容旧配置中不带单位的整数（如 timeout: 300），整数按秒解析，带小数的数字会被拒绝
+func toDurationE(v interface{}) (time.Duration, error) {
+	switch val := v.(type) {
+	case time.Duration:
+		return val, nil
+	case string:
+		s := strings.TrimSpace(val)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return secondsToDuration(n)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("无效的时间间隔 %q", val)
+		}
+		return d, nil
+	}
+	if n, err := toInt64E(v); err == nil {
+		return secondsToDuration(n)
+	}
+	return 0, fmt.Errorf("需要 5s、1m30s 形式的时间间隔或整数秒，实际为 %v", v)
+}
+
+// secondsToDuration 将整数秒转换为时间间隔，并检查是否溢出
+func secondsToDuration(n int64) (time.Duration, error) {
+	if n > int64(math.MaxInt64/time.Second) || n < int64(math.MinInt64/time.Second) {
+		return 0, fmt.Errorf("时间间隔 %d 秒超出范围", n)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+// toTimeE 转换为时间，字符串支持 RFC3339、2006-01-02 15:04:05、2006-01-02 等常见格式
+func toTimeE(v interface{}) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, nil
+	case string:
+		t, err := cast.StringToDate(strings.TrimSpace(val))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无法识别的时间格式 %q", val)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("需要时间，实际为 %T 类型的 %v", v, v)
+}
+
+// toStringSliceE 转换为字符串列表，字符串按 , 分隔，便于通过环境变量设置列表
+func toStringSliceE(v interface{}) ([]string, error) {
+	switch val := v.(type) {
+	case []string:
+		return append([]string(nil), val...), nil
+	case string:
+		var items []string
+		for _, item := range strings.Split(val, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			s, err := toStringE(item)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 项%v", i+1, err)
+			}
+			items[i] = s
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("需要列表，实际为 %T 类型的 %v", v, v)
+}
+
+// toStringMapE 转换为 map，键名统一为小写
+func toStringMapE(v interface{}) (map[string]interface{}, error) {
+	m, ok := toStringMap(v)
+	if !ok {
+		return nil, fmt.Errorf("需要 map，实际为 %T 类型的 %v", v, v)
+	}
+	return normalize(m), nil
+}
+
+// toStringMapStringE 转换为值均为字符串的 map
+func toStringMapStringE(v interface{}) (map[string]string, error) {
+	m, err := toStringMapE(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(m))
+	for k, item := range m {
+		s, err := toStringE(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s %v", k, err)
+		}
+		out[k] = s
+	}
+	return out, nil
+}
+
+// toByteSizeE 转换为字节大小，数字表示字节数，字符串支持 512KB、16MB、1G 等写法
+func toByteSizeE(v interface{}) (ByteSize, error) {
+	if s, ok := v.(string); ok {
+		n, err := util.ParseBytes(s)
+		return ByteSize(n), err
+	}
+	n, err := toInt64E(v)
+	if err != nil {
+		return 0, fmt.Errorf("需要字节大小，实际为 %v", v)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("无效的字节大小: %d", n)
+	}
+	return ByteSize(n), nil
+}
+
+// coerceHook 在 Bind 时使用与类型化读取方法相同的规则转换基础类型的字段
+func coerceHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if data == nil || to == durationType || to == byteSizeType {
+		return data, nil
+	}
+	if to == timeType {
+		return toTimeE(data)
+	}
+	switch from.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		if to.Kind() == reflect.String {
+			return toStringE(data)
+		}
+		return data, nil
+	}
+
+	switch to.Kind() {
+	case reflect.Bool:
+		return toBoolE(data)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		max := int64(math.MaxInt64) >> uint(64-to.Bits())
+		return toIntRangeE(data, -max-1, max)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		max := int64(math.MaxInt64)
+		if to.Bits() < 64 {
+			max = int64(1)<<uint(to.Bits()) - 1
+		}
+		return toIntRangeE(data, 0, max)
+	case reflect.Float32, reflect.Float64:
+		return toFloat64E(data)
+	case reflect.String:
+		return toStringE(data)
+	case reflect.Slice:
+		if s, ok := data.(string); ok && to.Elem().Kind() != reflect.Uint8 {
+			list, _ := toStringSliceE(s)
+			items := make([]interface{}, len(list))
+			for i, item := range list {
+				items[i] = item
+			}
+			return items, nil
+		}
+	}
+	return data, nil
+}
@@ -2,105 +2,323 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
 )
 
 var (
 	// 配置文件默认放置于项目下的一级目录 config 中
 	defaultConfigPath = "./config/"
+	configPathMu      sync.RWMutex
 )
 
+// EnvKey 指定应用运行环境的环境变量
+const EnvKey = "HULK_ENV"
+
 type Config struct {
-	loadPath string
-	isLoad   bool
-	v        *viper.Viper
+	loadPath  string
+	env       string
+	envPrefix string
+	overrides map[string]overrideValue
+	keyFile   string
+	resolver  SecretResolver
+
+	// base 通过 NewConfigFromMap 创建时使用的配置内容，fromMap 为 true 时不再读取配置文件
+	base    map[string]interface{}
+	fromMap bool
+	// loads 通过 Load、LoadReader 及 LoadFS 加载的配置，按加载的顺序合并
+	loads []layer
+	// layers 通过 AddSource 添加的配置来源，在配置文件之后按顺序合并
+	layers []Source
+
+	mu      sync.RWMutex
+	isLoad  bool
+	st      *state
+	version uint64
+	redact  *regexp.Regexp
+
+	// unloadedRead 配置尚未加载时读取配置只记录一次警告
+	unloadedRead sync.Once
+
+	watcher
+	listeners
+}
+
+// state 一次加载得到的完整配置内容，重新加载时整体替换
+type state struct {
+	// data 合并后的配置内容，v 由其生成，供 viper 的读取方法使用
+	data    map[string]interface{}
+	sources map[string]string
+	// secrets 由 ENC(...) 或 secret:// 解密得到的配置项
+	secrets map[string]bool
+	files   []string
+	v       *viper.Viper
+
+	// version 及 time 为该配置内容生效时的版本号及时间
+	version uint64
+	time    time.Time
+}
+
+func newState() *state {
+	return &state{
+		data:    make(map[string]interface{}),
+		sources: make(map[string]string),
+		secrets: make(map[string]bool),
+		v:       viper.New(),
+	}
+}
+
+// clone 返回配置内容的副本，用于在不影响当前配置的情况下修改
+func (st *state) clone() *state {
+	sources := make(map[string]string, len(st.sources))
+	for k, v := range st.sources {
+		sources[k] = v
+	}
+	secrets := make(map[string]bool, len(st.secrets))
+	for k, v := range st.secrets {
+		secrets[k] = v
+	}
+	return &state{
+		data:    normalize(st.data),
+		sources: sources,
+		secrets: secrets,
+		files:   append([]string(nil), st.files...),
+	}
 }
 
 // NewConfig 返回一个配置管理实例
+// 每个实例使用独立的 viper 实例，同一进程中的多个应用之间互不影响
 func NewConfig() *Config {
-	return &Config{
-		loadPath: defaultConfigPath,
-		isLoad:   false,
-		v:        viper.GetViper(),
+	c := &Config{
+		loadPath:  DefaultLoadPath(),
+		overrides: make(map[string]overrideValue),
+		st:        newState(),
 	}
+	c.SetEnvPrefix(DefaultEnvPrefix)
+	return c
+}
+
+// NewConfigFromMap 返回一个直接使用 map 内容作为配置的管理实例，不依赖任何配置文件
+// 之后调用 Load 及 LoadFiles 不会读取配置文件，LoadReader、LoadFS 及 AddSource 仍然生效
+// 使用独立的 viper 实例，多个实例之间互不影响，通常用于测试场景
+func NewConfigFromMap(m map[string]interface{}) *Config {
+	c := NewConfig()
+	c.base, c.fromMap = normalize(m), true
+	st, _ := c.build()
+	c.install(st)
+	c.isLoad = true
+	return c
 }
 
-// SetConfigLoadPath 可以设置配置文件的加载路径
+// SetConfigLoadPath 可以设置新建配置实例时默认的加载路径，已创建的实例不受影响
 // 未通过调用该方法设置配置文件路径时，默认会从 项目/config/ 目录下进行文件读取
+// 需要为单个应用指定路径时，使用 Config.SetLoadPath 或 boot.WithConfigPath
 func SetConfigLoadPath(path string) {
+	configPathMu.Lock()
+	defer configPathMu.Unlock()
 	defaultConfigPath = path
 }
 
-// Load 根据默认或应用指定的路径加载对应的配置文件
-func (c *Config) Load(file string) error {
-	if !c.isLoad {
-		c.v.SetConfigFile(c.loadPath + file)
-		if err := c.v.ReadInConfig(); err != nil {
-			return fmt.Errorf("配置文件 %s 加载失败 err: %v", file, err)
-		}
-		c.isLoad = true
+// DefaultLoadPath 返回新建配置实例时默认的加载路径
+func DefaultLoadPath() string {
+	configPathMu.RLock()
+	defer configPathMu.RUnlock()
+	return defaultConfigPath
+}
+
+// SetLoadPath 设置当前配置实例的配置文件加载路径，只影响当前实例
+func (c *Config) SetLoadPath(path string) {
+	if path != "" && !strings.HasSuffix(path, "/") {
+		path += "/"
 	}
-	return nil
+	c.loadPath = path
 }
 
-// GetViper 返回底层 viper 的实例
-func (c *Config) GetViper() *viper.Viper { return c.v }
+// SetEnv 指定应用的运行环境，优先于 HULK_ENV 环境变量及配置文件中的 app.env
+// 运行环境决定 Load 时合并的环境配置文件，并覆盖配置中的 app.env
+func (c *Config) SetEnv(env string) {
+	c.env = env
+	if env != "" {
+		c.override("app.env", env, sourceOverride)
+	}
+}
 
-// Get 根据提供的配置 Key 返回对应的配置内容
-// 如果配置文件未加载或加载失败时，将统一返回 nil
-func (c *Config) Get(key string) interface{} {
-	if !c.isLoad {
-		return nil
+// Env 返回当前配置对应的运行环境
+func (c *Config) Env() string {
+	if c.env != "" || !c.loaded() {
+		return c.env
 	}
-	return c.v.Get(key)
+	return c.GetString("app.env")
 }
 
-// GetDefault 在找不到可用的配置内容时，可以自定义返回的默认值
-func (c *Config) GetDefault(key string, dv interface{}) interface{} {
-	if v := c.Get(key); v != nil {
-		return v
+// Files 返回已加载的配置文件，按合并的先后顺序排列
+func (c *Config) Files() []string {
+	return append([]string(nil), c.current().files...)
+}
+
+// Load 根据默认或应用指定的路径加载对应的配置文件
+// 以 app.yaml 为例，依次加载并合并以下文件，后加载的文件优先：
+// 1. app.yaml：基础配置，必须存在
+// 2. app.{env}.yaml：环境配置，不存在时忽略
+// 3. app.local.yaml：本地配置，不存在时忽略，通常不提交到代码仓库中
+// 运行环境依次取 SetEnv 指定的环境、HULK_ENV 环境变量及 app.yaml 中的 app.env
+// 合并时嵌套的配置项逐层合并，列表及其他类型的值整体覆盖
+// 文件中的 ${VAR:default} 会替换为环境变量的值，最后再使用 HULK_ 前缀的环境变量覆盖同名配置项
+// 合并完成后解密 ENC(...) 形式的值，并通过 SetSecretResolver 设置的 SecretResolver 解析 secret:// 引用
+// 优先级由高到低为：Set、SetEnv 及 -set 等命令行参数、环境变量、AddSource 添加的配置来源、app.local.yaml、app.{env}.yaml、app.yaml
+// 可以多次调用以加载多个配置文件，参见 LoadFiles；已加载的文件再次加载时直接返回，file 为空时只加载 AddSource 添加的配置来源
+func (c *Config) Load(file string) error {
+	if file == "" {
+		return c.LoadFiles()
 	}
-	return dv
+	return c.LoadFiles(file)
 }
 
-// GetInt return a int
-func (c *Config) GetInt(key string) int {
-	if !c.isLoad {
-		return 0
+// build 按 Load 中的规则重新读取并合并所有配置，返回新的配置内容
+// 配置文件之后依次合并 AddSource 添加的配置来源，再使用环境变量及 Set 设置的配置项覆盖
+func (c *Config) build() (*state, error) {
+	st := newState()
+	st.data = normalize(c.base)
+
+	loads := c.loadLayers()
+	for _, l := range loads {
+		if err := st.merge(l.source(c.loadPath)); err != nil {
+			return nil, fmt.Errorf("%s 加载失败 err: %v", l, err)
+		}
+	}
+
+	env, envSource := c.env, sourceOverride
+	if env == "" {
+		env, envSource = os.Getenv(EnvKey), sourceEnv+EnvKey
+	}
+	if env == "" {
+		env = cast.ToString(lookup(st.data, "app.env"))
+	}
+
+	for _, l := range loads {
+		if l.file == "" {
+			continue
+		}
+		for _, overlay := range overlays(l.file, env) {
+			if err := st.merge(NewFileSource(c.loadPath+overlay, true)); err != nil {
+				return nil, fmt.Errorf("配置文件 %s 加载失败 err: %v", overlay, err)
+			}
+		}
+	}
+	for _, src := range c.addedSources() {
+		if err := st.merge(src); err != nil {
+			return nil, fmt.Errorf("配置来源 %s 加载失败 err: %v", src.Name(), err)
+		}
+	}
+	c.applyEnvOverrides(st)
+
+	if c.env == "" && env != "" && env != cast.ToString(lookup(st.data, "app.env")) {
+		setPath(st.data, "app.env", env)
+		st.sources["app.env"] = envSource
+	}
+	c.applyOverrides(st)
+	if err := c.resolveSecrets(st); err != nil {
+		return nil, err
 	}
-	return c.v.GetInt(key)
+	st.v = newViper(st.data)
+	return st, nil
 }
 
-func (c *Config) GetInt32(key string) int32 {
-	if !c.isLoad {
-		return 0
+// overlays 返回指定运行环境下配置文件需要合并的环境配置及本地配置文件名
+func overlays(file, env string) []string {
+	ext := filepath.Ext(file)
+	name := strings.TrimSuffix(file, ext)
+	var overlays []string
+	if env != "" {
+		overlays = append(overlays, name+"."+env+ext)
 	}
-	return c.v.GetInt32(key)
+	return append(overlays, name+".local"+ext)
 }
 
-// GetInt64 return a int64
-func (c *Config) GetInt64(key string) int64 {
-	if !c.isLoad {
-		return 0
+// merge 读取配置来源的内容，替换其中的 ${VAR:default} 后合并到配置内容中，并记录每个配置项的来源
+func (st *state) merge(src Source) error {
+	data, err := src.Load()
+	if err != nil || data == nil {
+		return err
+	}
+
+	data = normalize(data)
+	vars := interpolate("", data)
+	mergeMap(st.data, data)
+
+	name := src.Name()
+	for _, key := range keys(data) {
+		st.sources[key] = name
+		if v, ok := vars[key]; ok {
+			st.sources[key] += " ${" + v + "}"
+		}
+	}
+	if fs, ok := src.(*FileSource); ok {
+		st.files = append(st.files, fs.path)
 	}
-	return c.v.GetInt64(key)
+	return nil
+}
+
+func newViper(data map[string]interface{}) *viper.Viper {
+	v := viper.New()
+	_ = v.MergeConfigMap(data)
+	return v
 }
 
-// GetString return a string
-func (c *Config) GetString(key string) string {
-	if !c.isLoad {
-		return ""
+func (c *Config) loaded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.isLoad
+}
+
+// warnUnloaded 配置尚未加载时读取配置项通常是初始化顺序错误，读取方法会静默返回零值，因此记录一次警告便于排查
+func (c *Config) warnUnloaded(key string) {
+	c.unloadedRead.Do(func() {
+		log.Printf("配置尚未加载时读取了配置项 %s，将返回零值，需要先调用 Load 或应用的 Init", key)
+	})
+}
+
+// current 返回当前的配置内容，配置内容在重新加载时整体替换，读取到的内容不会再被修改
+func (c *Config) current() *state {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.st
+}
+
+// GetViper 返回底层 viper 的实例，配置重新加载后会返回新的实例
+func (c *Config) GetViper() *viper.Viper { return c.current().v }
+
+// Get 根据提供的配置 Key 返回对应的配置内容，配置项不存在时返回 nil
+// 配置文件中不存在该配置项时，使用对应的环境变量，如 HULK_GRPC_PORT 对应 grpc.port
+// 配置尚未加载时同样返回 nil 并记录一次警告，需要区分时使用 GetIntE 等方法，返回的 *KeyError 中包含 ErrNotLoaded
+func (c *Config) Get(key string) interface{} {
+	if !c.loaded() {
+		c.warnUnloaded(key)
+		return nil
 	}
-	return c.v.GetString(key)
+	if v := c.current().v.Get(key); v != nil {
+		return v
+	}
+	if v := os.Getenv(c.EnvName(key)); v != "" {
+		return v
+	}
+	return nil
 }
 
-// GetStringMap return a map
-func (c *Config) GetStringMap(key string) map[string]interface{} {
-	if !c.isLoad {
-		return make(map[string]interface{})
+// GetDefault 在找不到可用的配置内容时，可以自定义返回的默认值
+func (c *Config) GetDefault(key string, dv interface{}) interface{} {
+	if v := c.Get(key); v != nil {
+		return v
 	}
-	return c.v.GetStringMap(key)
+	return dv
 }
 
 // IsProdEnv 判断当前应用的运行环境是否为生产环境
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "hulk-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestConfigInstancesAreIsolated(t *testing.T) {
+	dirA, dirB := tempDir(t), tempDir(t)
+	writeConfig(t, dirA, "app.yaml", "app:\n  name: a\n")
+	writeConfig(t, dirB, "app.yaml", "app:\n  name: b\n")
+
+	a, b := NewConfig(), NewConfig()
+	a.SetLoadPath(dirA)
+	b.SetLoadPath(dirB)
+	if err := a.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.GetString("app.name") != "a" || b.GetString("app.name") != "b" {
+		t.Fatalf("app.name = %q, %q, want a, b", a.GetString("app.name"), b.GetString("app.name"))
+	}
+}
+
+func TestSetConfigLoadPathOnlyAffectsNewInstances(t *testing.T) {
+	old := DefaultLoadPath()
+	defer SetConfigLoadPath(old)
+
+	before := NewConfig()
+	SetConfigLoadPath("/etc/hulk/")
+	after := NewConfig()
+
+	if before.loadPath != old || after.loadPath != "/etc/hulk/" {
+		t.Fatalf("loadPath = %q, %q", before.loadPath, after.loadPath)
+	}
+}
+
+func newOverlayConfig(t *testing.T) (*Config, string) {
+	t.Helper()
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", `
+app:
+  env: dev
+  name: demo
+mongo:
+  host: 127.0.0.1
+  port: 27017
+http:
+  cors:
+    allow_origins: ["*"]
+`)
+	writeConfig(t, dir, "app.dev.yaml", `
+mongo:
+  host: mongo.dev
+http:
+  cors:
+    allow_origins: ["https://dev.example.com", "https://admin.example.com"]
+`)
+	writeConfig(t, dir, "app.prod.yaml", `
+mongo:
+  host: mongo.prod
+`)
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	return c, dir
+}
+
+func TestLoadMergesEnvOverlay(t *testing.T) {
+	os.Unsetenv(EnvKey)
+	c, dir := newOverlayConfig(t)
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 嵌套的配置项逐层合并
+	if c.GetString("mongo.host") != "mongo.dev" || c.GetInt("mongo.port") != 27017 {
+		t.Fatalf("mongo = %v", c.GetStringMap("mongo"))
+	}
+	// 列表整体覆盖
+	if origins := c.GetViper().GetStringSlice("http.cors.allow_origins"); len(origins) != 2 {
+		t.Fatalf("allow_origins = %v", origins)
+	}
+	if files := c.Files(); len(files) != 2 || files[1] != filepath.Join(dir, "app.dev.yaml") {
+		t.Fatalf("files = %v", files)
+	}
+}
+
+func TestLoadEnvPrecedence(t *testing.T) {
+	defer os.Unsetenv(EnvKey)
+
+	// HULK_ENV 优先于 app.env
+	os.Setenv(EnvKey, "prod")
+	c, _ := newOverlayConfig(t)
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetString("mongo.host") != "mongo.prod" || !c.IsProdEnv() {
+		t.Fatalf("mongo.host = %q, env = %q", c.GetString("mongo.host"), c.Env())
+	}
+
+	// SetEnv 优先于 HULK_ENV
+	c, _ = newOverlayConfig(t)
+	c.SetEnv("test")
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetString("mongo.host") != "127.0.0.1" || c.Env() != "test" || c.GetString("app.env") != "test" {
+		t.Fatalf("mongo.host = %q, env = %q", c.GetString("mongo.host"), c.Env())
+	}
+}
+
+func TestLoadMergesLocalOverlayLast(t *testing.T) {
+	os.Unsetenv(EnvKey)
+	c, dir := newOverlayConfig(t)
+	writeConfig(t, dir, "app.local.yaml", `
+mongo:
+  host: localhost
+`)
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GetString("mongo.host") != "localhost" || c.GetString("app.name") != "demo" {
+		t.Fatalf("mongo.host = %q, app.name = %q", c.GetString("mongo.host"), c.GetString("app.name"))
+	}
+	if files := c.Files(); len(files) != 3 || files[2] != filepath.Join(dir, "app.local.yaml") {
+		t.Fatalf("files = %v", files)
+	}
+}
+
+func TestLoadRequiresBaseFile(t *testing.T) {
+	c := NewConfig()
+	c.SetLoadPath(tempDir(t))
+	if err := c.Load("app.yaml"); err == nil {
+		t.Fatal("Load should fail when the base file is missing")
+	}
+}
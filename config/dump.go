@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Dump 按配置项名称的顺序输出合并后的配置内容，每行末尾注明该配置项的来源，敏感配置项的值会被隐藏
+//
+//	grpc.port       = 9100         # env:HULK_GRPC_PORT
+//	mongo.host      = 127.0.0.1    # file:config/app.yaml
+//	mongo.password  = ******       # file:config/app.yaml
+func (c *Config) Dump(w io.Writer) error { return c.Snapshot().Dump(w) }
+
+// Dump 按 Config.Dump 的格式输出该版本的配置内容
+func (s *Snapshot) Dump(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, key := range s.Keys() {
+		value := formatValue(lookup(s.st.data, key))
+		if s.IsSensitive(key) {
+			value = secretMask
+		}
+		line := fmt.Sprintf("%s\t= %v", key, value)
+		if src := s.Source(key); src != "" {
+			line += "\t# " + src
+		}
+		if _, err := fmt.Fprintln(tw, line); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = formatValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return "{}"
+		}
+	}
+	return fmt.Sprint(v)
+}
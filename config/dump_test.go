@@ -0,0 +1,41 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", "grpc:\n  port: 9000\nhttp:\n  cors:\n    allow_origins: [a, b]\n")
+	setenv(t, "HULK_GRPC_PORT", "9100")
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	c.Set("app.name", "demo")
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := [][]string{
+		{"app.name", "= demo", "# override"},
+		{"grpc.port", "= 9100", "# env:HULK_GRPC_PORT"},
+		{"http.cors.allow_origins", "= [a, b]", "# file:" + dir + "/app.yaml"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("dump:\n%s", buf.String())
+	}
+	for i, parts := range want {
+		for _, p := range parts {
+			if !strings.Contains(lines[i], p) {
+				t.Errorf("line %q should contain %q", lines[i], p)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultEnvPrefix 通过环境变量覆盖配置项时默认使用的前缀
+const DefaultEnvPrefix = "HULK"
+
+// 配置项来源的标识，Source 返回的内容以此开头
+const (
+	sourceFile     = "file:"
+	sourceEnv      = "env:"
+	sourceOverride = "override"
+)
+
+// interpolation 匹配配置值中的 ${VAR} 及 ${VAR:default}
+var interpolation = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::([^}]*))?\}`)
+
+// SetEnvPrefix 设置通过环境变量覆盖配置项时使用的前缀，默认为 HULK，需在 Load 之前调用
+// 配置项对应的环境变量名为 前缀_配置项，其中 . 替换为 _ 并转为大写，如 HULK_MONGO_PASSWORD 对应 mongo.password
+// 前缀为空时直接使用配置项转换后的名称
+func (c *Config) SetEnvPrefix(prefix string) {
+	c.envPrefix = prefix
+}
+
+// EnvName 返回可以覆盖指定配置项的环境变量名
+func (c *Config) EnvName(key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if c.envPrefix == "" {
+		return name
+	}
+	return strings.ToUpper(c.envPrefix) + "_" + name
+}
+
+// applyEnvOverrides 使用环境变量覆盖配置文件中已有的配置项
+// 覆盖的值会合并到配置内容中，因此读取整段配置（如 GetStringMap、UnmarshalKey）时同样生效；
+// 配置文件中不存在的配置项，通过 Get 等方法按完整的配置项读取时同样会使用对应的环境变量；值为空的环境变量会被忽略
+func (c *Config) applyEnvOverrides(st *state) {
+	for _, key := range keys(st.data) {
+		name := c.EnvName(key)
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		setPath(st.data, key, value)
+		st.sources[key] = sourceEnv + name
+	}
+}
+
+// interpolate 将配置内容中字符串值里的 ${VAR:default} 替换为环境变量的值，环境变量不存在时使用默认值
+// 返回发生替换的配置项及其使用的环境变量名
+func interpolate(prefix string, m map[string]interface{}) map[string]string {
+	vars := make(map[string]string)
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		var name string
+		m[k], name = interpolateValue(key, v, vars)
+		if name != "" {
+			vars[key] = name
+		}
+	}
+	return vars
+}
+
+func interpolateValue(key string, v interface{}, vars map[string]string) (interface{}, string) {
+	switch val := v.(type) {
+	case string:
+		return expand(val)
+	case map[string]interface{}:
+		for k, name := range interpolate(key, val) {
+			vars[k] = name
+		}
+	case []interface{}:
+		var used string
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			var name string
+			if list[i], name = interpolateValue(key, item, vars); name != "" && used == "" {
+				used = name
+			}
+		}
+		return list, used
+	}
+	return v, ""
+}
+
+// expand 替换字符串中的 ${VAR:default}，返回替换后的内容及第一个使用的环境变量名
+func expand(s string) (string, string) {
+	var used string
+	out := interpolation.ReplaceAllStringFunc(s, func(match string) string {
+		sub := interpolation.FindStringSubmatch(match)
+		if used == "" {
+			used = sub[1]
+		}
+		if value, ok := os.LookupEnv(sub[1]); ok {
+			return value
+		}
+		return sub[2]
+	})
+	return out, used
+}
+
+// Source 返回配置项当前的取值来源：
+// file:<路径> 表示来自配置文件，其后带有 ${VAR} 时表示值中引用了环境变量，AddSource 添加的来源为其 Name 的返回值；
+// env:<变量名> 表示来自环境变量；override 表示由代码或命令行参数指定；未设置时返回空字符串
+func (c *Config) Source(key string) string {
+	key = strings.ToLower(key)
+	st := c.current()
+	if src, ok := st.sources[key]; ok {
+		return src
+	}
+	if name := c.EnvName(key); lookup(st.data, key) == nil && os.Getenv(name) != "" {
+		return sourceEnv + name
+	}
+	return ""
+}
+
+// Sources 返回所有配置项及其取值来源
+func (c *Config) Sources() map[string]string {
+	sources := make(map[string]string)
+	for _, key := range keys(c.current().data) {
+		if src := c.Source(key); src != "" {
+			sources[key] = src
+		}
+	}
+	return sources
+}
+
+// overrideValue 通过代码或命令行参数设置的配置项及其来源
+type overrideValue struct {
+	value  interface{}
+	source string
+}
+
+// Set 设置配置项的值，优先于环境变量及所有配置文件
+// 在 Load 之前调用时，会在配置文件加载完成后生效；加载后调用时会通知 OnChange 注册的监听函数
+func (c *Config) Set(key string, value interface{}) {
+	c.override(strings.ToLower(key), value, sourceOverride)
+}
+
+func (c *Config) override(key string, value interface{}, source string) {
+	c.mu.Lock()
+	c.overrides[key] = overrideValue{value: value, source: source}
+	if !c.isLoad {
+		c.mu.Unlock()
+		return
+	}
+	st := c.st.clone()
+	c.mu.Unlock()
+
+	c.applyOverrides(st)
+	// 解析失败的配置项保持原值，加密内容本身可以公开
+	_ = c.resolveSecrets(st)
+	st.v = newViper(st.data)
+	c.swap(st)
+}
+
+// applyOverrides 将 Set 设置的配置项合并到配置内容中
+// 与环境变量相同，合并后读取整段配置时同样生效
+func (c *Config) applyOverrides(st *state) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for key, o := range c.overrides {
+		setPath(st.data, key, o.value)
+		st.sources[key] = o.source
+	}
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestEnvOverridesFileValues(t *testing.T) {
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", `
+grpc:
+  port: 9000
+mongo:
+  host: 127.0.0.1
+  password: secret
+`)
+	setenv(t, "HULK_GRPC_PORT", "9100")
+	setenv(t, "HULK_MONGO_PASSWORD", "from-env")
+	setenv(t, "HULK_HTTP_PORT", "8100")
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GetInt("grpc.port") != 9100 || c.GetString("mongo.password") != "from-env" {
+		t.Fatalf("grpc.port = %d, mongo.password = %q", c.GetInt("grpc.port"), c.GetString("mongo.password"))
+	}
+	// 整段读取时同样使用环境变量覆盖后的值
+	if c.GetStringMap("mongo")["password"] != "from-env" {
+		t.Fatalf("mongo = %v", c.GetStringMap("mongo"))
+	}
+	// 配置文件中不存在的配置项同样可以通过环境变量设置
+	if c.GetInt64("http.port") != 8100 {
+		t.Fatalf("http.port = %d", c.GetInt64("http.port"))
+	}
+
+	sources := map[string]string{
+		"grpc.port":  "env:HULK_GRPC_PORT",
+		"http.port":  "env:HULK_HTTP_PORT",
+		"mongo.host": "file:" + dir + "/app.yaml",
+	}
+	for key, want := range sources {
+		if got := c.Source(key); got != want {
+			t.Errorf("Source(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestEnvPrefix(t *testing.T) {
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", "grpc:\n  port: 9000\n")
+	setenv(t, "HULK_GRPC_PORT", "9100")
+	setenv(t, "ORDER_GRPC_PORT", "9200")
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	c.SetEnvPrefix("order")
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetInt("grpc.port") != 9200 {
+		t.Fatalf("grpc.port = %d, want 9200", c.GetInt("grpc.port"))
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", `
+mongo:
+  host: ${MONGO_HOST:127.0.0.1}
+  port: ${MONGO_PORT:27017}
+  url: mongodb://${MONGO_USER}@${MONGO_HOST:localhost}
+http:
+  cors:
+    allow_origins: ["${ORIGIN:*}"]
+`)
+	setenv(t, "MONGO_HOST", "mongo.internal")
+	os.Unsetenv("MONGO_PORT")
+	os.Unsetenv("MONGO_USER")
+	os.Unsetenv("ORIGIN")
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GetString("mongo.host") != "mongo.internal" || c.GetInt64("mongo.port") != 27017 {
+		t.Fatalf("mongo = %v", c.GetStringMap("mongo"))
+	}
+	if c.GetString("mongo.url") != "mongodb://@mongo.internal" {
+		t.Fatalf("mongo.url = %q", c.GetString("mongo.url"))
+	}
+	if origins := c.GetViper().GetStringSlice("http.cors.allow_origins"); len(origins) != 1 || origins[0] != "*" {
+		t.Fatalf("allow_origins = %v", origins)
+	}
+	if got, want := c.Source("mongo.host"), "file:"+dir+"/app.yaml ${MONGO_HOST}"; got != want {
+		t.Fatalf("Source = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ErrNotSet 读取的配置项不存在时，KeyError 中包含的错误
+var ErrNotSet = errors.New("配置项未设置")
+
+// KeyError 按类型读取配置项失败时返回的错误，Err 为 ErrNotLoaded、ErrNotSet 或类型转换失败的原因
+//
+// 各类型的读取方法均有三种形式，以 Int 为例：
+//  1. GetInt 配置尚未加载、配置项不存在或类型错误时返回零值
+//  2. GetIntDefault 配置尚未加载、配置项不存在或类型错误时返回指定的默认值
+//  3. GetIntE 以上情况均返回 *KeyError，可以通过 errors.Is(err, ErrNotLoaded)、errors.Is(err, ErrNotSet) 区分
+//
+// 所有来源使用相同的转换规则，例如 YAML 中的 8080、JSON 中的 8080.0 及环境变量中的 "8080" 均可以读取为整数
+// 需要将整段配置读取为结构体时使用 Bind
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string { return e.Key + ": " + e.Err.Error() }
+
+func (e *KeyError) Unwrap() error { return e.Err }
+
+// IsSet 判断配置项是否存在，包括配置文件中不存在但设置了对应环境变量的配置项
+func (c *Config) IsSet(key string) bool { return c.Get(key) != nil }
+
+// read 读取配置项并按 conv 转换类型，配置尚未加载、配置项不存在或转换失败时返回 *KeyError
+func (c *Config) read(key string, conv func(v interface{}) error) error {
+	if !c.loaded() {
+		c.warnUnloaded(key)
+		return &KeyError{Key: key, Err: ErrNotLoaded}
+	}
+	v := c.Get(key)
+	if v == nil {
+		return &KeyError{Key: key, Err: ErrNotSet}
+	}
+	if err := conv(v); err != nil {
+		if c.IsSensitive(key) {
+			err = fmt.Errorf("类型错误")
+		}
+		return &KeyError{Key: key, Err: err}
+	}
+	return nil
+}
+
+func toIntE(v interface{}) (int, error) {
+	max := int64(math.MaxInt64) >> uint(64-strconv.IntSize)
+	n, err := toIntRangeE(v, -max-1, max)
+	return int(n), err
+}
+
+func toInt32E(v interface{}) (int32, error) {
+	n, err := toIntRangeE(v, math.MinInt32, math.MaxInt32)
+	return int32(n), err
+}
+
+// GetBoolE 读取布尔值，字符串支持 true/false、1/0、yes/no、on/off
+func (c *Config) GetBoolE(key string) (bool, error) {
+	var out bool
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toBoolE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetBool 配置项不存在或类型错误时返回零值
+func (c *Config) GetBool(key string) bool {
+	v, _ := c.GetBoolE(key)
+	return v
+}
+
+// GetBoolDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetBoolDefault(key string, def bool) bool {
+	if v, err := c.GetBoolE(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetIntE 读取 int 类型的配置项
+func (c *Config) GetIntE(key string) (int, error) {
+	var out int
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toIntE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetInt 配置项不存在或类型错误时返回零值
+func (c *Config) GetInt(key string) int {
+	v, _ := c.GetIntE(key)
+	return v
+}
+
+// GetIntDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetIntDefault(key string, def int) int {
+	if v, err := c.GetIntE(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetInt32E 读取 int32 类型的配置项
+func (c *Config) GetInt32E(key string) (int32, error) {
+	var out int32
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toInt32E(v)
+		return err
+	})
+	return out, err
+}
+
+// GetInt32 配置项不存在或类型错误时返回零值
+func (c *Config) GetInt32(key string) int32 {
+	v, _ := c.GetInt32E(key)
+	return v
+}
+
+// GetInt32Default 配置项不存在或类型错误时返回 def
+func (c *Config) GetInt32Default(key string, def int32) int32 {
+	if v, err := c.GetInt32E(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetInt64E 读取 int64 类型的配置项
+func (c *Config) GetInt64E(key string) (int64, error) {
+	var out int64
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toInt64E(v)
+		return err
+	})
+	return out, err
+}
+
+// GetInt64 配置项不存在或类型错误时返回零值
+func (c *Config) GetInt64(key string) int64 {
+	v, _ := c.GetInt64E(key)
+	return v
+}
+
+// GetInt64Default 配置项不存在或类型错误时返回 def
+func (c *Config) GetInt64Default(key string, def int64) int64 {
+	if v, err := c.GetInt64E(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetFloat64E 读取 float64 类型的配置项
+func (c *Config) GetFloat64E(key string) (float64, error) {
+	var out float64
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toFloat64E(v)
+		return err
+	})
+	return out, err
+}
+
+// GetFloat64 配置项不存在或类型错误时返回零值
+func (c *Config) GetFloat64(key string) float64 {
+	v, _ := c.GetFloat64E(key)
+	return v
+}
+
+// GetFloat64Default 配置项不存在或类型错误时返回 def
+func (c *Config) GetFloat64Default(key string, def float64) float64 {
+	if v, err := c.GetFloat64E(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetStringE 读取字符串，数字及布尔值会转换为对应的文本，map 与列表视为类型错误
+func (c *Config) GetStringE(key string) (string, error) {
+	var out string
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toStringE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetString 配置项不存在或类型错误时返回零值
+func (c *Config) GetString(key string) string {
+	v, _ := c.GetStringE(key)
+	return v
+}
+
+// GetStringDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetStringDefault(key string, def string) string {
+	if v, err := c.GetStringE(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetDurationE 读取时间间隔，需要配置为 5s、1m30s 等字符串，数字视为类型错误
+func (c *Config) GetDurationE(key string) (time.Duration, error) {
+	var out time.Duration
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toDurationE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetDuration 配置项不存在或类型错误时返回零值
+func (c *Config) GetDuration(key string) time.Duration {
+	v, _ := c.GetDurationE(key)
+	return v
+}
+
+// GetDurationDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetDurationDefault(key string, def time.Duration) time.Duration {
+	if v, err := c.GetDurationE(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetTimeE 读取时间，支持 YAML、TOML 中的时间及 RFC3339、2006-01-02 等格式的字符串
+func (c *Config) GetTimeE(key string) (time.Time, error) {
+	var out time.Time
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toTimeE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetTime 配置项不存在或类型错误时返回零值
+func (c *Config) GetTime(key string) time.Time {
+	v, _ := c.GetTimeE(key)
+	return v
+}
+
+// GetTimeDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetTimeDefault(key string, def time.Time) time.Time {
+	if v, err := c.GetTimeE(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetStringSliceE 读取字符串列表，字符串按 , 分隔，如 HULK_HTTP_GIN_PREFIXES=/hooks,/oauth
+func (c *Config) GetStringSliceE(key string) ([]string, error) {
+	var out []string
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toStringSliceE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetStringSlice 配置项不存在或类型错误时返回零值
+func (c *Config) GetStringSlice(key string) []string {
+	v, _ := c.GetStringSliceE(key)
+	return v
+}
+
+// GetStringSliceDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetStringSliceDefault(key string, def []string) []string {
+	if v, err := c.GetStringSliceE(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetStringMapE 读取 map 类型的配置项
+func (c *Config) GetStringMapE(key string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toStringMapE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetStringMap 配置项不存在或类型错误时返回零值
+func (c *Config) GetStringMap(key string) map[string]interface{} {
+	v, _ := c.GetStringMapE(key)
+	return v
+}
+
+// GetStringMapDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetStringMapDefault(key string, def map[string]interface{}) map[string]interface{} {
+	if v, err := c.GetStringMapE(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetStringMapStringE 读取值均为字符串的 map
+func (c *Config) GetStringMapStringE(key string) (map[string]string, error) {
+	var out map[string]string
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toStringMapStringE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetStringMapString 配置项不存在或类型错误时返回零值
+func (c *Config) GetStringMapString(key string) map[string]string {
+	v, _ := c.GetStringMapStringE(key)
+	return v
+}
+
+// GetStringMapStringDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetStringMapStringDefault(key string, def map[string]string) map[string]string {
+	if v, err := c.GetStringMapStringE(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetByteSizeE 读取字节大小，数字表示字节数，字符串支持 512KB、16MB、1G 等写法
+func (c *Config) GetByteSizeE(key string) (ByteSize, error) {
+	var out ByteSize
+	err := c.read(key, func(v interface{}) (err error) {
+		out, err = toByteSizeE(v)
+		return err
+	})
+	return out, err
+}
+
+// GetByteSize 配置项不存在或类型错误时返回零值
+func (c *Config) GetByteSize(key string) ByteSize {
+	v, _ := c.GetByteSizeE(key)
+	return v
+}
+
+// GetByteSizeDefault 配置项不存在或类型错误时返回 def
+func (c *Config) GetByteSizeDefault(key string, def ByteSize) ByteSize {
+	if v, err := c.GetByteSizeE(key); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGettersCoerceConsistentlyAcrossFormats(t *testing.T) {
+	files := map[string]string{
+		"app.yaml": "app:\n  port: 8080\n  debug: true\n  ratio: 0.5\n  timeout: 5s\n  hosts: [a, b]\n  started: 2021-06-01T08:00:00Z\n",
+		"app.json": `{"app": {"port": 8080, "debug": true, "ratio": 0.5, "timeout": "5s", "hosts": ["a", "b"], "started": "2021-06-01T08:00:00Z"}}`,
+		"app.toml": "[app]\nport = 8080\ndebug = true\nratio = 0.5\ntimeout = \"5s\"\nhosts = [\"a\", \"b\"]\nstarted = 2021-06-01T08:00:00Z\n",
+	}
+	started := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	for name, content := range files {
+		dir := tempDir(t)
+		writeConfig(t, dir, name, content)
+		c := NewConfig()
+		c.SetLoadPath(dir)
+		if err := c.Load(name); err != nil {
+			t.Fatal(err)
+		}
+
+		if v, err := c.GetIntE("app.port"); err != nil || v != 8080 {
+			t.Errorf("%s: GetIntE = %v, %v", name, v, err)
+		}
+		if v, err := c.GetBoolE("app.debug"); err != nil || !v {
+			t.Errorf("%s: GetBoolE = %v, %v", name, v, err)
+		}
+		if v, err := c.GetFloat64E("app.ratio"); err != nil || v != 0.5 {
+			t.Errorf("%s: GetFloat64E = %v, %v", name, v, err)
+		}
+		if v, err := c.GetDurationE("app.timeout"); err != nil || v != 5*time.Second {
+			t.Errorf("%s: GetDurationE = %v, %v", name, v, err)
+		}
+		if v, err := c.GetStringSliceE("app.hosts"); err != nil || len(v) != 2 || v[1] != "b" {
+			t.Errorf("%s: GetStringSliceE = %v, %v", name, v, err)
+		}
+		if v, err := c.GetTimeE("app.started"); err != nil || !v.Equal(started) {
+			t.Errorf("%s: GetTimeE = %v, %v", name, v, err)
+		}
+	}
+}
+
+func TestGettersFromEnv(t *testing.T) {
+	setenv(t, "HULK_APP_PORT", "9090")
+	setenv(t, "HULK_APP_DEBUG", "yes")
+	setenv(t, "HULK_APP_HOSTS", "a, b")
+	c := NewConfigFromMap(map[string]interface{}{"app": map[string]interface{}{"name": "demo"}})
+
+	if c.GetInt("app.port") != 9090 || !c.GetBool("app.debug") {
+		t.Fatalf("port = %d, debug = %v", c.GetInt("app.port"), c.GetBool("app.debug"))
+	}
+	if hosts := c.GetStringSlice("app.hosts"); len(hosts) != 2 || hosts[1] != "b" {
+		t.Fatalf("hosts = %q", hosts)
+	}
+}
+
+func TestGettersReportMissingAndInvalid(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"app": map[string]interface{}{"port": "80x", "ratio": 1.5, "timeout": 0.5},
+	})
+
+	if c.IsSet("app.missing") || !c.IsSet("app.port") {
+		t.Fatal("IsSet mismatch")
+	}
+	if _, err := c.GetIntE("app.missing"); !errors.Is(err, ErrNotSet) {
+		t.Fatalf("GetIntE(missing) error = %v, want ErrNotSet", err)
+	}
+	for _, err := range []error{
+		func() error { _, err := c.GetIntE("app.port"); return err }(),
+		func() error { _, err := c.GetIntE("app.ratio"); return err }(),
+		func() error { _, err := c.GetDurationE("app.timeout"); return err }(),
+	} {
+		if err == nil || errors.Is(err, ErrNotSet) {
+			t.Fatalf("error = %v, want type error", err)
+		}
+	}
+	if c.GetIntDefault("app.port", 8080) != 8080 || c.GetDurationDefault("app.missing", time.Second) != time.Second {
+		t.Fatal("Default did not fall back")
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ConfigVersionHeader 配置中心返回配置内容时，用于标识配置版本的响应头
+const ConfigVersionHeader = "X-Config-Version"
+
+// HTTPSource 从配置中心通过 HTTP 长轮询读取配置
+// 配置中心需要支持以下两种请求：
+// 1. GET <url>：立即返回完整的配置内容，并通过 X-Config-Version 响应头返回当前版本
+// 2. GET <url>?version=<版本>&timeout=<时长>：版本发生变化时返回 200 及新的配置内容，
+// 直到超时仍未变化时返回 304
+// 每次读取成功后会将配置内容写入缓存文件，配置中心不可用时使用缓存文件中最后一次成功读取的内容
+type HTTPSource struct {
+	url         string
+	format      string
+	cacheFile   string
+	client      *http.Client
+	pollTimeout time.Duration
+	retryDelay  time.Duration
+
+	mu        sync.Mutex
+	version   string
+	fromCache bool
+}
+
+// HTTPSourceOption HTTPSource 的可选配置
+type HTTPSourceOption func(*HTTPSource)
+
+// WithFormat 指定配置内容的格式，默认根据 URL 的扩展名判断，无法判断时为 yaml
+func WithFormat(format string) HTTPSourceOption {
+	return func(s *HTTPSource) { s.format = format }
+}
+
+// WithCacheFile 指定缓存配置内容的文件，为空时不缓存
+func WithCacheFile(file string) HTTPSourceOption {
+	return func(s *HTTPSource) { s.cacheFile = file }
+}
+
+// WithHTTPClient 指定请求配置中心使用的 http.Client
+func WithHTTPClient(client *http.Client) HTTPSourceOption {
+	return func(s *HTTPSource) { s.client = client }
+}
+
+// WithPollTimeout 指定长轮询请求的超时时间，默认为 30s
+func WithPollTimeout(d time.Duration) HTTPSourceOption {
+	return func(s *HTTPSource) { s.pollTimeout = d }
+}
+
+// WithRetryDelay 指定长轮询请求失败后重试的间隔，默认为 5s
+func WithRetryDelay(d time.Duration) HTTPSourceOption {
+	return func(s *HTTPSource) { s.retryDelay = d }
+}
+
+// NewHTTPSource 返回一个从配置中心读取配置的来源
+func NewHTTPSource(rawURL string, opts ...HTTPSourceOption) *HTTPSource {
+	s := &HTTPSource{
+		url:         rawURL,
+		client:      http.DefaultClient,
+		pollTimeout: 30 * time.Second,
+		retryDelay:  5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.format == "" {
+		s.format = "yaml"
+		if u, err := url.Parse(rawURL); err == nil && path.Ext(u.Path) != "" {
+			s.format = path.Ext(u.Path)[1:]
+		}
+	}
+	return s
+}
+
+// Name 返回配置中心的地址，使用缓存文件中的内容时会额外标注
+func (s *HTTPSource) Name() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fromCache {
+		return "http:" + s.url + " (cache)"
+	}
+	return "http:" + s.url
+}
+
+// Load 从配置中心读取配置，失败时使用缓存文件中的内容，两者均不可用时返回错误
+func (s *HTTPSource) Load() (map[string]interface{}, error) {
+	content, version, err := s.fetch(context.Background(), "")
+	if err == nil {
+		var data map[string]interface{}
+		if data, err = parseConfig(s.format, content); err == nil {
+			s.setVersion(version, false)
+			s.writeCache(content)
+			return data, nil
+		}
+	}
+
+	if s.cacheFile == "" {
+		return nil, fmt.Errorf("配置中心 %s 读取失败 err: %v", s.url, err)
+	}
+	cached, cacheErr := ioutil.ReadFile(s.cacheFile)
+	if cacheErr != nil {
+		return nil, fmt.Errorf("配置中心 %s 读取失败且没有可用的缓存 err: %v", s.url, err)
+	}
+	data, cacheErr := parseConfig(s.format, cached)
+	if cacheErr != nil {
+		return nil, fmt.Errorf("配置中心 %s 读取失败且缓存内容无法解析 err: %v", s.url, err)
+	}
+	s.setVersion("", true)
+	return data, nil
+}
+
+// Watch 通过长轮询等待配置版本变化，直到 ctx 结束
+// 请求失败或响应中缺少版本号时按 retryDelay 重试；配置中心没有等待就返回配置未变更时，
+// 同样至少间隔 retryDelay 才发起下一次请求，避免不支持长轮询的配置中心导致空转
+func (s *HTTPSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		s.mu.Lock()
+		version := s.version
+		s.mu.Unlock()
+
+		start := time.Now()
+		_, next, err := s.fetch(ctx, version)
+		var wait time.Duration
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err == nil && next == "":
+			// 缺少版本号时无法发起长轮询，视为可重试的错误
+			wait = s.retryDelay
+		case err == errNotModified, err == nil && next == version:
+			wait = s.retryDelay - time.Since(start)
+		case err != nil:
+			wait = s.retryDelay
+		default:
+			s.setVersion(next, false)
+			changed()
+		}
+
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+var errNotModified = fmt.Errorf("配置未变更")
+
+// fetch 请求配置中心，version 不为空时发起长轮询
+func (s *HTTPSource) fetch(ctx context.Context, version string) ([]byte, string, error) {
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return nil, "", err
+	}
+	if version != "" {
+		q := u.Query()
+		q.Set("version", version)
+		q.Set("timeout", s.pollTimeout.String())
+		u.RawQuery = q.Encode()
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.pollTimeout+10*time.Second)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		return body, resp.Header.Get(ConfigVersionHeader), err
+	case http.StatusNotModified:
+		return nil, version, errNotModified
+	default:
+		return nil, "", fmt.Errorf("配置中心返回异常状态码 %d", resp.StatusCode)
+	}
+}
+
+func (s *HTTPSource) setVersion(version string, fromCache bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version, s.fromCache = version, fromCache
+}
+
+// writeCache 通过临时文件及重命名写入缓存，避免进程异常退出时留下不完整的缓存
+func (s *HTTPSource) writeCache(content []byte) {
+	if s.cacheFile == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cacheFile), os.ModePerm); err != nil {
+		return
+	}
+	tmp := s.cacheFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, s.cacheFile)
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+)
+
+// layer 通过 Load、LoadReader 及 LoadFS 加载的一项配置
+// file 为加载路径下的配置文件名，合并时同时合并其环境配置及本地配置；src 为直接读取的配置内容
+type layer struct {
+	file string
+	src  Source
+}
+
+func (l layer) source(loadPath string) Source {
+	if l.src != nil {
+		return l.src
+	}
+	return NewFileSource(loadPath+l.file, false)
+}
+
+func (l layer) String() string {
+	if l.src != nil {
+		return "配置来源 " + l.src.Name()
+	}
+	return "配置文件 " + l.file
+}
+
+// LoadFiles 依次加载并合并多个配置文件，后加载的文件优先，文件格式根据扩展名判断，支持 viper 支持的所有格式
+//
+//	c.LoadFiles("app.yaml", "mongo.yaml", "clients.json")
+//
+// 每个文件都会按 Load 中的规则合并其环境配置及本地配置，如 mongo.prod.yaml、mongo.local.yaml，
+// 这些文件在所有基础配置文件之后合并；已加载的文件再次加载时忽略
+// 配置已加载时会重新加载全部配置并通知 OnChange 注册的监听函数，加载失败时返回错误，当前配置保持不变
+func (c *Config) LoadFiles(files ...string) error {
+	if c.fromMap {
+		files = nil
+	}
+	add := make([]layer, 0, len(files))
+	for _, file := range files {
+		add = append(add, layer{file: file})
+	}
+	return c.load(add)
+}
+
+// LoadReader 从 r 中读取配置内容并按加载的顺序合并，name 用于根据扩展名判断格式并记录配置项的来源，如 defaults.yaml
+func (c *Config) LoadReader(name string, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return c.load([]layer{{src: NewBytesSource(name, path.Ext(name), content)}})
+}
+
+// LoadFS 从 fsys 中依次加载配置文件并按加载的顺序合并，通常用于程序内嵌的默认配置
+//
+//	//go:embed config/app.yaml
+//	var defaults embed.FS
+//
+//	c.LoadFS(defaults, "config/app.yaml")
+//	c.Load("app.yaml")
+//
+// 在 Load 之前调用时，内嵌的配置优先级低于配置文件，可以作为默认值使用
+func (c *Config) LoadFS(fsys fs.FS, files ...string) error {
+	add := make([]layer, 0, len(files))
+	for _, file := range files {
+		add = append(add, layer{src: NewFSSource(fsys, file)})
+	}
+	return c.load(add)
+}
+
+// load 追加需要加载的配置，未加载时直接加载全部配置，已加载时重新加载
+func (c *Config) load(add []layer) error {
+	c.mu.Lock()
+	n := len(c.loads)
+	for _, l := range add {
+		if l.file == "" || !c.hasFile(l.file) {
+			c.loads = append(c.loads, l)
+		}
+	}
+	added, loaded := len(c.loads) > n, c.isLoad
+	c.mu.Unlock()
+	if loaded && !added {
+		return nil
+	}
+
+	var err error
+	if loaded {
+		err = c.Reload()
+	} else {
+		var st *state
+		if st, err = c.build(); err == nil {
+			c.mu.Lock()
+			c.install(st)
+			c.isLoad = true
+			c.mu.Unlock()
+		}
+	}
+	if err != nil {
+		c.mu.Lock()
+		c.loads = c.loads[:n]
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// hasFile 判断配置文件是否已经加载，调用方需持有 mu
+func (c *Config) hasFile(file string) bool {
+	for _, l := range c.loads {
+		if l.file == file {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) loadLayers() []layer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]layer(nil), c.loads...)
+}
+
+// baseFiles 返回通过 Load 及 LoadFiles 加载的配置文件名
+func (c *Config) baseFiles() []string {
+	var files []string
+	for _, l := range c.loadLayers() {
+		if l.file != "" {
+			files = append(files, l.file)
+		}
+	}
+	return files
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFilesMergesInOrder(t *testing.T) {
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", "app:\n  env: prod\n  name: demo\nmongo:\n  host: app\n")
+	writeConfig(t, dir, "mongo.json", `{"mongo": {"host": "mongo", "port": 27017}}`)
+	writeConfig(t, dir, "mongo.prod.json", `{"mongo": {"port": 27018}}`)
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	if err := c.LoadFiles("app.yaml", "mongo.json"); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetString("mongo.host") != "mongo" || c.GetInt("mongo.port") != 27018 || c.GetString("app.name") != "demo" {
+		t.Fatalf("config = %v", c.GetStringMap(""))
+	}
+	if files := c.Files(); len(files) != 3 {
+		t.Fatalf("Files = %q", files)
+	}
+}
+
+func TestLoadAddsFileAfterLoaded(t *testing.T) {
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", "grpc:\n  port: 9100\n")
+	writeConfig(t, dir, "clients.yaml", "clients:\n  user: user-svc:9100\n")
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	var changed interface{}
+	c.OnChange("clients.user", func(_, new interface{}) { changed = new })
+
+	if err := c.Load("clients.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if changed != "user-svc:9100" || c.GetInt("grpc.port") != 9100 {
+		t.Fatalf("changed = %v, grpc.port = %d", changed, c.GetInt("grpc.port"))
+	}
+	if err := c.Load("missing.yaml"); err == nil {
+		t.Fatal("Load(missing.yaml) succeeded")
+	}
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatalf("loading app.yaml again: %v", err)
+	}
+}
+
+func TestLoadFSAndReaderAsDefaults(t *testing.T) {
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", "http:\n  port: 8080\n")
+	embedded := fstest.MapFS{
+		"config/app.yaml": {Data: []byte("http:\n  port: 80\n  timeout: 5s\n")},
+	}
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	if err := c.LoadFS(embedded, "config/app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadReader("extra.toml", strings.NewReader("[http]\nmax_body = \"4MB\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GetInt("http.port") != 8080 || c.GetString("http.timeout") != "5s" || c.GetString("http.max_body") != "4MB" {
+		t.Fatalf("http = %v", c.GetStringMap("http"))
+	}
+	if src := c.Source("http.timeout"); src != "fs:config/app.yaml" {
+		t.Fatalf("Source(http.timeout) = %q", src)
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// mergeMap 将 src 深度合并到 dst 中：两边都是 map 的配置项逐层合并，其他情况下 src 中的值整体覆盖 dst
+// 与 viper 的合并不同，类型不一致时同样以 src 为准，例如环境变量中的字符串可以覆盖文件中的数字
+func mergeMap(dst, src map[string]interface{}) {
+	for k, sv := range src {
+		sm, srcIsMap := toStringMap(sv)
+		dm, dstIsMap := toStringMap(dst[k])
+		if srcIsMap && dstIsMap {
+			mergeMap(dm, sm)
+			dst[k] = dm
+			continue
+		}
+		if srcIsMap {
+			sv = normalize(sm)
+		}
+		dst[k] = sv
+	}
+}
+
+// normalize 返回键名统一为小写的配置内容副本，与 viper 的键名规则保持一致
+func normalize(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if sub, ok := toStringMap(v); ok {
+			v = normalize(sub)
+		}
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		return cast.ToStringMap(m), true
+	}
+	return nil, false
+}
+
+// setPath 按 a.b.c 形式的配置项设置值，路径中间不是 map 的部分会被替换
+func setPath(m map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(strings.ToLower(key), ".")
+	for _, p := range parts[:len(parts)-1] {
+		sub, ok := toStringMap(m[p])
+		if !ok {
+			sub = make(map[string]interface{})
+		}
+		m[p] = sub
+		m = sub
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// lookup 按 a.b.c 形式的配置项读取值，不存在时返回 nil
+func lookup(m map[string]interface{}, key string) interface{} {
+	var v interface{} = m
+	for _, p := range strings.Split(strings.ToLower(key), ".") {
+		sub, ok := toStringMap(v)
+		if !ok {
+			return nil
+		}
+		if v, ok = sub[p]; !ok {
+			return nil
+		}
+	}
+	return v
+}
+
+// keys 返回配置内容中所有叶子配置项的完整名称，按名称排序
+func keys(m map[string]interface{}) []string {
+	var out []string
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			if sub, ok := toStringMap(v); ok && len(sub) > 0 {
+				walk(key, sub)
+				continue
+			}
+			out = append(out, key)
+		}
+	}
+	walk("", m)
+	sort.Strings(out)
+	return out
+}
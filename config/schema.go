@@ -0,0 +1,99 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrNotLoaded 配置尚未加载时读取配置返回的错误
+var ErrNotLoaded = errors.New("配置尚未加载，需要先调用 Load 或应用的 Init")
+
+// schema 组件通过 Require 声明的配置结构
+type schema struct {
+	key   string
+	value reflect.Value
+}
+
+// ValidationError 配置校验失败时返回的错误，包含所有声明的配置中存在的问题
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("配置校验失败，共 %d 个问题:\n  %s", len(e.Problems), strings.Join(e.Problems, "\n  "))
+}
+
+// Require 声明组件依赖的配置，key 下的内容需要能够通过 Bind 绑定到 prototype 指向的结构体
+// 必填项、类型及取值范围通过结构体的 mapstructure、default 及 validate 标签声明，规则与 Bind 一致
+//
+//	c.Require("grpc", &struct {
+//		Port int `mapstructure:"port" validate:"required,min=1,max=65535"`
+//	}{})
+//
+// 声明的配置在 Validate 及 Reload 时统一校验，prototype 中已有的字段值作为默认值使用
+// 同一个 key 重复声明相同类型的结构体时只保留第一次的声明，应用重新初始化时不会重复报告问题
+func (c *Config) Require(key string, prototype interface{}) {
+	rv := reflect.ValueOf(prototype)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("config.Require: prototype 需要是结构体指针，实际为 %T", prototype))
+	}
+
+	key = strings.ToLower(key)
+	c.lmu.Lock()
+	defer c.lmu.Unlock()
+	for _, s := range c.schemas {
+		if s.key == key && s.value.Type() == rv.Elem().Type() {
+			return
+		}
+	}
+	c.schemas = append(c.schemas, schema{key: key, value: rv.Elem()})
+}
+
+// Validate 校验所有通过 Require 声明的配置，返回的 *ValidationError 中包含全部缺失或无效的配置项
+func (c *Config) Validate() error {
+	if !c.loaded() {
+		return ErrNotLoaded
+	}
+	return c.validateSchemas(c)
+}
+
+// validateSchemas 使用当前实例中声明的配置结构校验 target 的配置内容
+func (c *Config) validateSchemas(target *Config) error {
+	c.lmu.Lock()
+	schemas := append([]schema(nil), c.schemas...)
+	c.lmu.Unlock()
+
+	verr := &ValidationError{}
+	for _, s := range schemas {
+		out := reflect.New(s.value.Type())
+		out.Elem().Set(s.value)
+		if err := target.Bind(s.key, out.Interface()); err != nil {
+			if be, ok := err.(*BindError); ok {
+				verr.Problems = append(verr.Problems, be.Problems...)
+			} else {
+				verr.Problems = append(verr.Problems, err.Error())
+			}
+		}
+	}
+
+	if len(verr.Problems) > 0 {
+		verr.Problems = dedupe(verr.Problems)
+		return verr
+	}
+	return nil
+}
+
+// dedupe 去除多个组件声明同一配置项时产生的重复问题，保持原有顺序
+func dedupe(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	out := items[:0]
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+type listenSchema struct {
+	Port int `mapstructure:"port" validate:"required,min=1,max=65535"`
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"grpc":  map[string]interface{}{"prot": 9100},
+		"http":  map[string]interface{}{"port": 70000},
+		"mongo": map[string]interface{}{"port": "abc"},
+	})
+	c.Require("grpc", &listenSchema{})
+	c.Require("http", &listenSchema{})
+	c.Require("mongo", &listenSchema{})
+	// 重复声明不会重复报告问题
+	c.Require("MONGO", &listenSchema{})
+
+	err := c.Validate()
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("Validate error = %v, want *ValidationError", err)
+	}
+	if len(verr.Problems) != 3 {
+		t.Fatalf("problems = %q", verr.Problems)
+	}
+	for _, key := range []string{"grpc.port", "http.port", "mongo"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Fatalf("error %q does not mention %s", err, key)
+		}
+	}
+}
+
+func TestReloadRejectsRequiredViolation(t *testing.T) {
+	c, dir := newReloadConfig(t)
+	c.Require("mongo", &listenSchema{})
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfig(t, dir, "app.yaml", "mongo:\n  host: b\n")
+	if err := c.Reload(); err == nil {
+		t.Fatal("Reload accepted config without mongo.port")
+	}
+	if c.GetInt("mongo.port") != 27017 {
+		t.Fatalf("mongo.port = %d, want old value kept", c.GetInt("mongo.port"))
+	}
+}
+
+func TestReadBeforeLoad(t *testing.T) {
+	c := NewConfig()
+	if err := c.Bind("mongo", &listenSchema{}); err != ErrNotLoaded {
+		t.Fatalf("Bind error = %v, want ErrNotLoaded", err)
+	}
+	if _, err := c.GetIntE("grpc.port"); !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("GetIntE error = %v, want ErrNotLoaded", err)
+	}
+	var ke *KeyError
+	if _, err := c.GetStringE("app.name"); !errors.As(err, &ke) || ke.Key != "app.name" {
+		t.Fatalf("GetStringE error = %v, want *KeyError", err)
+	}
+	if c.Get("grpc.port") != nil || c.IsSet("grpc.port") || c.GetInt("grpc.port") != 0 || c.GetIntDefault("grpc.port", 9000) != 9000 {
+		t.Fatal("getters before Load should return zero or default values")
+	}
+}
+
+func TestReadBeforeLoadWarnsOnce(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	c := NewConfig()
+	c.GetString("app.name")
+	c.GetInt("grpc.port")
+	c.Get("mongo.host")
+	if n := strings.Count(buf.String(), "配置尚未加载"); n != 1 || !strings.Contains(buf.String(), "app.name") {
+		t.Fatalf("log output = %q, want a single warning for app.name", buf.String())
+	}
+}
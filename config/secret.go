@@ -0,0 +1,214 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 读取解密密钥的环境变量，密钥为 base64 编码的 16、24 或 32 字节，分别对应 AES-128、AES-192、AES-256
+const (
+	SecretKeyEnv     = "HULK_SECRET_KEY"
+	SecretKeyFileEnv = "HULK_SECRET_KEY_FILE"
+)
+
+// 加密配置值及外部密钥引用的格式
+const (
+	encPrefix    = "ENC("
+	encSuffix    = ")"
+	secretScheme = "secret://"
+)
+
+// secretMask 输出配置内容时替换敏感配置项的值
+const secretMask = "******"
+
+// SecretResolver 解析 secret://path 形式的外部密钥引用，如从 Vault 等密钥管理服务中读取
+type SecretResolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// SecretResolverFunc 将普通函数转换为 SecretResolver
+type SecretResolverFunc func(ref string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ref string) (string, error) { return f(ref) }
+
+// SetSecretResolver 设置解析 secret:// 引用使用的 SecretResolver，需在 Load 之前调用
+func (c *Config) SetSecretResolver(r SecretResolver) {
+	c.resolver = r
+}
+
+// SetSecretKeyFile 指定解密 ENC(...) 配置值时使用的密钥文件，需在 Load 之前调用
+// 未指定时依次使用 HULK_SECRET_KEY 环境变量中的密钥及 HULK_SECRET_KEY_FILE 环境变量指定的密钥文件
+func (c *Config) SetSecretKeyFile(path string) {
+	c.keyFile = path
+}
+
+// IsSecret 判断配置项的值是否由 ENC(...) 或 secret:// 解密得到，这类配置项在 Dump 等输出中会被隐藏
+func (c *Config) IsSecret(key string) bool {
+	return c.current().secrets[strings.ToLower(key)]
+}
+
+// LoadSecretKey 读取解密密钥，keyFile 不为空时优先读取该文件，否则依次使用 HULK_SECRET_KEY 及 HULK_SECRET_KEY_FILE
+func LoadSecretKey(keyFile string) ([]byte, error) {
+	encoded := os.Getenv(SecretKeyEnv)
+	if keyFile == "" && encoded == "" {
+		keyFile = os.Getenv(SecretKeyFileEnv)
+	}
+	if keyFile != "" {
+		b, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("密钥文件 %s 读取失败 err: %v", keyFile, err)
+		}
+		encoded = string(b)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("未设置解密密钥，需要通过 %s 或 %s 环境变量指定", SecretKeyEnv, SecretKeyFileEnv)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("解密密钥需要使用 base64 编码 err: %v", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("解密密钥长度应为 16、24 或 32 字节，实际为 %d 字节", len(key))
+}
+
+// GenerateSecretKey 生成一个 base64 编码的 AES-256 密钥
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Encrypt 使用 AES-GCM 加密配置值，返回可以直接写入配置文件的 ENC(...) 形式
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed) + encSuffix, nil
+}
+
+// Decrypt 解密 Encrypt 返回的 ENC(...) 形式的配置值
+func Decrypt(key []byte, value string) (string, error) {
+	if !isEncrypted(value) {
+		return "", fmt.Errorf("配置值不是 ENC(...) 形式")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(encPrefix) : len(value)-len(encSuffix)])
+	if err != nil {
+		return "", fmt.Errorf("加密内容需要使用 base64 编码 err: %v", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("加密内容长度不足")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("解密失败，请确认密钥是否正确")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func isEncrypted(s string) bool {
+	return strings.HasPrefix(s, encPrefix) && strings.HasSuffix(s, encSuffix)
+}
+
+// resolveSecrets 解密配置内容中 ENC(...) 形式的值，并通过 SecretResolver 解析 secret:// 引用
+// 密钥只在存在加密值时读取，所有无法解析的配置项汇总后返回，错误信息中不包含配置值
+func (c *Config) resolveSecrets(st *state) error {
+	var secretKey []byte
+	var keyErr error
+	var problems []string
+	resolve := func(s string) (string, bool, error) {
+		switch {
+		case isEncrypted(s):
+			if secretKey == nil && keyErr == nil {
+				secretKey, keyErr = LoadSecretKey(c.keyFile)
+			}
+			if keyErr != nil {
+				return "", true, keyErr
+			}
+			plain, err := Decrypt(secretKey, s)
+			return plain, true, err
+		case strings.HasPrefix(s, secretScheme):
+			if c.resolver == nil {
+				return "", true, fmt.Errorf("未设置 SecretResolver")
+			}
+			plain, err := c.resolver.Resolve(s)
+			return plain, true, err
+		}
+		return s, false, nil
+	}
+
+	for _, key := range keys(st.data) {
+		var secret bool
+		var err error
+		switch v := lookup(st.data, key).(type) {
+		case string:
+			var plain string
+			if plain, secret, err = resolve(v); secret && err == nil {
+				setPath(st.data, key, plain)
+			}
+		case []interface{}:
+			list := make([]interface{}, len(v))
+			for i, item := range v {
+				list[i] = item
+				s, ok := item.(string)
+				if !ok {
+					continue
+				}
+				plain, isSecret, e := resolve(s)
+				if isSecret {
+					secret = true
+					list[i] = plain
+				}
+				if e != nil && err == nil {
+					err = e
+				}
+			}
+			if secret && err == nil {
+				setPath(st.data, key, list)
+			}
+		}
+		if secret {
+			st.secrets[key] = true
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("敏感配置解析失败: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
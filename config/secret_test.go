@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	encoded, err := GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := base64.StdEncoding.DecodeString(encoded)
+
+	enc, err := Encrypt(key, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "ENC(") || strings.Contains(enc, "s3cret") {
+		t.Fatalf("Encrypt = %q", enc)
+	}
+	if plain, err := Decrypt(key, enc); err != nil || plain != "s3cret" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+
+	other := make([]byte, 32)
+	if _, err := Decrypt(other, enc); err == nil {
+		t.Fatal("Decrypt with wrong key succeeded")
+	}
+}
+
+func TestConfigDecryptsSecrets(t *testing.T) {
+	dir := tempDir(t)
+	encoded, _ := GenerateSecretKey()
+	key, _ := base64.StdEncoding.DecodeString(encoded)
+	enc, _ := Encrypt(key, "s3cret")
+	writeConfig(t, dir, "secret.key", encoded+"\n")
+	writeConfig(t, dir, "app.yaml", "mongo:\n  host: db\n  password: "+enc+"\n  token: secret://mongo/token\n")
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	c.SetSecretKeyFile(filepath.Join(dir, "secret.key"))
+	c.SetSecretResolver(SecretResolverFunc(func(ref string) (string, error) {
+		return "resolved:" + strings.TrimPrefix(ref, "secret://"), nil
+	}))
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GetString("mongo.password") != "s3cret" || c.GetString("mongo.token") != "resolved:mongo/token" {
+		t.Fatalf("mongo = %v", c.GetStringMap("mongo"))
+	}
+	if !c.IsSecret("mongo.password") || !c.IsSecret("mongo.token") || c.IsSecret("mongo.host") {
+		t.Fatal("IsSecret mismatch")
+	}
+
+	var buf bytes.Buffer
+	if err := c.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "s3cret") || strings.Contains(buf.String(), "resolved:") {
+		t.Fatalf("Dump leaked secret:\n%s", buf.String())
+	}
+}
+
+func TestConfigSecretWithoutKey(t *testing.T) {
+	setenv(t, SecretKeyEnv, "")
+	setenv(t, SecretKeyFileEnv, "")
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", "mongo:\n  password: ENC(AAAA)\n")
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	err := c.Load("app.yaml")
+	if err == nil || !strings.Contains(err.Error(), "mongo.password") {
+		t.Fatalf("Load error = %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultRedactPattern 默认视为敏感信息的配置项名称，输出配置内容时隐藏其取值
+var DefaultRedactPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private_key|api_?key)`)
+
+// Snapshot 某一版本的完整配置内容，创建后不会再被修改，读取到的 map 及列表均为副本
+// 每次加载、重新加载或加载后调用 Set 都会生成新的版本，版本号从 1 开始递增
+type Snapshot struct {
+	st     *state
+	redact *regexp.Regexp
+}
+
+// Change 两个版本之间发生变更的配置项，新增时 Old 为 nil，删除时 New 为 nil
+type Change struct {
+	Key       string
+	Old       interface{}
+	New       interface{}
+	Sensitive bool
+}
+
+// String 返回变更的描述，敏感配置项的取值会被隐藏
+func (ch Change) String() string {
+	format := func(v interface{}) string {
+		switch {
+		case v == nil:
+			return "<未设置>"
+		case ch.Sensitive:
+			return secretMask
+		}
+		return formatValue(v)
+	}
+	return fmt.Sprintf("%s: %s -> %s", ch.Key, format(ch.Old), format(ch.New))
+}
+
+// ReloadFunc 配置内容更新后的回调函数，changes 为两个版本之间发生变更的配置项
+type ReloadFunc func(prev, next *Snapshot, changes []Change)
+
+// OnReload 注册配置内容更新后的回调函数，包括重新加载、加载新的配置文件及加载后调用 Set，通常用于记录配置变更
+func (c *Config) OnReload(fn ReloadFunc) {
+	c.lmu.Lock()
+	defer c.lmu.Unlock()
+	c.reloads = append(c.reloads, fn)
+}
+
+// SetRedactPattern 设置视为敏感信息的配置项名称，默认为 DefaultRedactPattern
+// 名称匹配的配置项及 ENC(...)、secret:// 解密得到的配置项在 Dump 及变更记录中均会被隐藏
+func (c *Config) SetRedactPattern(re *regexp.Regexp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.redact = re
+}
+
+// Snapshot 返回当前版本的配置内容
+func (c *Config) Snapshot() *Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &Snapshot{st: c.st, redact: c.redactPattern()}
+}
+
+// IsSensitive 判断配置项是否为敏感信息
+func (c *Config) IsSensitive(key string) bool { return c.Snapshot().IsSensitive(key) }
+
+// redactPattern 调用方需持有 mu
+func (c *Config) redactPattern() *regexp.Regexp {
+	if c.redact != nil {
+		return c.redact
+	}
+	return DefaultRedactPattern
+}
+
+// install 将新的配置内容设为当前版本，返回原有的配置内容，调用方需持有 mu
+func (c *Config) install(st *state) *state {
+	old := c.st
+	c.version++
+	st.version, st.time = c.version, time.Now()
+	c.st = st
+	return old
+}
+
+// Version 返回配置的版本号，未加载时为 0
+func (s *Snapshot) Version() uint64 { return s.st.version }
+
+// Time 返回该版本生效的时间
+func (s *Snapshot) Time() time.Time { return s.st.time }
+
+// Get 返回配置项的值，配置项不存在时返回 nil
+func (s *Snapshot) Get(key string) interface{} {
+	v := lookup(s.st.data, strings.ToLower(key))
+	if m, ok := toStringMap(v); ok {
+		return normalize(m)
+	}
+	if list, ok := v.([]interface{}); ok {
+		return append([]interface{}(nil), list...)
+	}
+	return v
+}
+
+// Keys 返回所有配置项的名称，按名称排序
+func (s *Snapshot) Keys() []string { return keys(s.st.data) }
+
+// Source 返回配置项的来源，规则与 Config.Source 一致
+func (s *Snapshot) Source(key string) string { return s.st.sources[strings.ToLower(key)] }
+
+// IsSensitive 判断配置项是否为敏感信息，即名称匹配敏感信息规则，或取值由 ENC(...)、secret:// 解密得到
+func (s *Snapshot) IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	return s.st.secrets[key] || s.redact.MatchString(key)
+}
+
+// Redacted 返回所有配置项及其取值，敏感配置项的取值替换为 ******
+func (s *Snapshot) Redacted() map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, key := range s.Keys() {
+		if s.IsSensitive(key) {
+			out[key] = secretMask
+			continue
+		}
+		out[key] = s.Get(key)
+	}
+	return out
+}
+
+// Diff 返回两个版本之间新增、删除或取值不同的配置项，按名称排序；a 为 nil 时视为空配置
+func Diff(a, b *Snapshot) []Change {
+	var old map[string]interface{}
+	if a != nil {
+		old = a.st.data
+	}
+	changed := changedKeys(old, b.st.data)
+	changes := make([]Change, 0, len(changed))
+	for _, key := range changed {
+		changes = append(changes, Change{
+			Key:       key,
+			Old:       lookup(old, key),
+			New:       lookup(b.st.data, key),
+			Sensitive: b.IsSensitive(key) || (a != nil && a.IsSensitive(key)),
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
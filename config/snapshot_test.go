@@ -0,0 +1,76 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotIsImmutableAndVersioned(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"mongo": map[string]interface{}{"host": "a", "hosts": []interface{}{"a"}},
+	})
+	first := c.Snapshot()
+	if first.Version() != 1 {
+		t.Fatalf("Version = %d, want 1", first.Version())
+	}
+
+	first.Get("mongo").(map[string]interface{})["host"] = "changed"
+	first.Get("mongo.hosts").([]interface{})[0] = "changed"
+	if first.Get("mongo.host") != "a" || first.Get("mongo.hosts").([]interface{})[0] != "a" {
+		t.Fatal("snapshot was modified through a returned value")
+	}
+
+	c.Set("mongo.host", "b")
+	second := c.Snapshot()
+	if second.Version() != 2 || first.Get("mongo.host") != "a" || second.Get("mongo.host") != "b" {
+		t.Fatalf("versions = %d, %d", first.Version(), second.Version())
+	}
+	c.Set("mongo.host", "b")
+	if c.Snapshot().Version() != 2 {
+		t.Fatal("setting the same value created a new version")
+	}
+}
+
+func TestDiffAndOnReload(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"mongo": map[string]interface{}{"host": "a", "password": "old", "port": 27017},
+	})
+	var logged []string
+	c.OnReload(func(prev, next *Snapshot, changes []Change) {
+		for _, ch := range changes {
+			logged = append(logged, ch.String())
+		}
+	})
+	before := c.Snapshot()
+	c.Set("mongo.password", "new")
+	c.Set("app.name", "demo")
+
+	changes := Diff(before, c.Snapshot())
+	if len(changes) != 2 || changes[0].Key != "app.name" || changes[1].Key != "mongo.password" || !changes[1].Sensitive {
+		t.Fatalf("Diff = %+v", changes)
+	}
+	want := []string{"mongo.password: ****** -> ******", "app.name: <未设置> -> demo"}
+	if strings.Join(logged, "|") != strings.Join(want, "|") {
+		t.Fatalf("logged = %q", logged)
+	}
+}
+
+func TestDumpRedactsSensitiveKeys(t *testing.T) {
+	c := NewConfigFromMap(map[string]interface{}{
+		"mongo": map[string]interface{}{"host": "db", "password": "p@ss"},
+		"oauth": map[string]interface{}{"client_secret": "s3cret", "access_token": "t0ken"},
+	})
+	var buf bytes.Buffer
+	if err := c.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"p@ss", "s3cret", "t0ken"} {
+		if strings.Contains(buf.String(), leaked) {
+			t.Fatalf("Dump leaked %q:\n%s", leaked, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), "db") || c.Snapshot().Redacted()["mongo.password"] != secretMask {
+		t.Fatalf("Dump:\n%s", buf.String())
+	}
+}
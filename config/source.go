@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Source 配置内容的来源，多个来源按添加的顺序合并，后添加的优先
+type Source interface {
+	// Name 返回来源的名称，用于记录配置项的来源，如 file:config/app.yaml
+	Name() string
+	// Load 读取完整的配置内容，返回 nil 表示该来源当前没有任何配置
+	Load() (map[string]interface{}, error)
+	// Watch 监听配置内容的变更，变更时调用 changed，直到 ctx 结束时返回；不支持监听的来源直接返回 nil
+	Watch(ctx context.Context, changed func()) error
+}
+
+// AddSource 添加一个配置来源，多个来源在配置文件之后按添加的顺序合并，优先于配置文件，低于环境变量及 Set
+//
+//	c.AddSource(config.NewHTTPSource("http://config-center/app/order.yaml",
+//		config.WithCacheFile("./runtime/config/order.yaml")))
+//
+// 已加载的配置会立即重新加载，加载失败时返回错误并移除该来源；需在 Watch 之前添加，Watch 时会同时监听其变更
+func (c *Config) AddSource(src Source) error {
+	c.mu.Lock()
+	c.layers = append(c.layers, src)
+	loaded := c.isLoad
+	c.mu.Unlock()
+	if !loaded {
+		return nil
+	}
+
+	if err := c.Reload(); err != nil {
+		c.mu.Lock()
+		c.layers = c.layers[:len(c.layers)-1]
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (c *Config) addedSources() []Source {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Source(nil), c.layers...)
+}
+
+// FileSource 从本地文件读取配置，文件格式根据扩展名判断，支持 viper 支持的所有格式
+type FileSource struct {
+	path     string
+	optional bool
+}
+
+// NewFileSource 返回一个读取指定文件的配置来源
+// optional 为 true 时文件不存在不会报错，之后新建该文件同样会触发变更
+func NewFileSource(path string, optional bool) *FileSource {
+	return &FileSource{path: path, optional: optional}
+}
+
+func (s *FileSource) Name() string { return sourceFile + s.path }
+
+func (s *FileSource) Load() (map[string]interface{}, error) {
+	if _, err := os.Stat(s.path); s.optional && os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(s.path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// Watch 监听文件所在的目录，以便处理编辑器通过重命名保存文件及文件新建的情况
+func (s *FileSource) Watch(ctx context.Context, changed func()) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+	if err := fsw.Add(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+
+	name := filepath.Clean(s.path)
+	for {
+		select {
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == name && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				changed()
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// BytesSource 从内存中的内容读取配置，通常用于程序内嵌的默认配置
+type BytesSource struct {
+	name    string
+	format  string
+	content []byte
+}
+
+// NewBytesSource 返回一个读取指定内容的配置来源，format 为 yaml、json、toml 等 viper 支持的格式
+func NewBytesSource(name, format string, content []byte) *BytesSource {
+	return &BytesSource{name: name, format: format, content: content}
+}
+
+func (s *BytesSource) Name() string { return "bytes:" + s.name }
+
+func (s *BytesSource) Load() (map[string]interface{}, error) {
+	return parseConfig(s.format, s.content)
+}
+
+func (s *BytesSource) Watch(context.Context, func()) error { return nil }
+
+// FSSource 从 fs.FS 中读取配置文件，通常为通过 go:embed 内嵌到程序中的默认配置
+type FSSource struct {
+	fsys fs.FS
+	path string
+}
+
+// NewFSSource 返回一个读取 fsys 中指定文件的配置来源，文件格式根据扩展名判断
+func NewFSSource(fsys fs.FS, path string) *FSSource {
+	return &FSSource{fsys: fsys, path: path}
+}
+
+func (s *FSSource) Name() string { return "fs:" + s.path }
+
+func (s *FSSource) Load() (map[string]interface{}, error) {
+	content, err := fs.ReadFile(s.fsys, s.path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfig(filepath.Ext(s.path), content)
+}
+
+func (s *FSSource) Watch(context.Context, func()) error { return nil }
+
+// parseConfig 按指定格式解析配置内容
+func parseConfig(format string, content []byte) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(format, "."))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("%s 格式的配置内容解析失败 err: %v", format, err)
+	}
+	return v.AllSettings(), nil
+}
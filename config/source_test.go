@@ -0,0 +1,189 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// configCenter 模拟配置中心，支持长轮询
+type configCenter struct {
+	mu      sync.Mutex
+	version int
+	content string
+	changed chan struct{}
+}
+
+func newConfigCenter(content string) *configCenter {
+	return &configCenter{version: 1, content: content, changed: make(chan struct{})}
+}
+
+func (cc *configCenter) publish(content string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.version++
+	cc.content = content
+	close(cc.changed)
+	cc.changed = make(chan struct{})
+}
+
+func (cc *configCenter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cc.mu.Lock()
+	version, content, changed := cc.version, cc.content, cc.changed
+	cc.mu.Unlock()
+
+	if v := r.URL.Query().Get("version"); v == strconv.Itoa(version) {
+		select {
+		case <-changed:
+			cc.mu.Lock()
+			version, content = cc.version, cc.content
+			cc.mu.Unlock()
+		case <-time.After(200 * time.Millisecond):
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.Header().Set(ConfigVersionHeader, strconv.Itoa(version))
+	_, _ = w.Write([]byte(content))
+}
+
+func TestSourcesMergeInOrder(t *testing.T) {
+	dir := tempDir(t)
+	writeConfig(t, dir, "app.yaml", "app:\n  name: file\n  port: 8080\n  debug: true\n")
+	server := httptest.NewServer(newConfigCenter("app:\n  name: remote\n"))
+	defer server.Close()
+
+	c := NewConfig()
+	c.SetLoadPath(dir)
+	_ = c.AddSource(NewBytesSource("defaults", "json", []byte(`{"app": {"port": 9090, "timeout": "5s"}}`)))
+	_ = c.AddSource(NewHTTPSource(server.URL + "/app.yaml"))
+	if err := c.Load("app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GetString("app.name") != "remote" || c.GetInt("app.port") != 9090 || c.GetString("app.timeout") != "5s" {
+		t.Fatalf("app = %v", c.GetStringMap("app"))
+	}
+	if c.Get("app.debug") != true {
+		t.Fatalf("app.debug = %v, want kept from file", c.Get("app.debug"))
+	}
+	if src := c.Source("app.name"); src != "http:"+server.URL+"/app.yaml" {
+		t.Fatalf("Source(app.name) = %q", src)
+	}
+	if src := c.Source("app.port"); src != "bytes:defaults" {
+		t.Fatalf("Source(app.port) = %q", src)
+	}
+}
+
+func TestHTTPSourceFallsBackToCache(t *testing.T) {
+	cache := filepath.Join(tempDir(t), "cache", "app.yaml")
+	server := httptest.NewServer(newConfigCenter("mongo:\n  host: remote\n"))
+	first := NewHTTPSource(server.URL, WithCacheFile(cache))
+	if _, err := first.Load(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	c := NewConfig()
+	_ = c.AddSource(NewHTTPSource(server.URL, WithCacheFile(cache)))
+	if err := c.Load(""); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetString("mongo.host") != "remote" {
+		t.Fatalf("mongo.host = %q, want cached value", c.GetString("mongo.host"))
+	}
+	if src := c.Source("mongo.host"); !strings.HasSuffix(src, "(cache)") {
+		t.Fatalf("Source(mongo.host) = %q", src)
+	}
+
+	if _, err := NewHTTPSource(server.URL).Load(); err == nil {
+		t.Fatal("Load without cache succeeded while config center is down")
+	}
+}
+
+func TestHTTPSourceWatchReloads(t *testing.T) {
+	center := newConfigCenter("logger:\n  level: info\n")
+	server := httptest.NewServer(center)
+	defer server.Close()
+
+	c := NewConfig()
+	_ = c.AddSource(NewHTTPSource(server.URL, WithPollTimeout(time.Second), WithRetryDelay(10*time.Millisecond)))
+	if err := c.Load(""); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := make(chan interface{}, 1)
+	c.OnChange("logger.level", func(old, new interface{}) { changed <- new })
+	if err := c.Watch(func(err error) { t.Log(err) }); err != nil {
+		t.Fatal(err)
+	}
+	defer c.StopWatch()
+
+	center.publish("logger:\n  level: debug\n")
+	select {
+	case level := <-changed:
+		if level != "debug" {
+			t.Fatalf("logger.level = %v, want debug", level)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded after the config center changed")
+	}
+}
+
+func TestHTTPSourceWatchBacksOff(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		// 响应中没有版本号
+		"no version": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("logger:\n  level: info\n"))
+		},
+		// 不支持长轮询，立即返回 304
+		"immediate 304": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("version") != "" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set(ConfigVersionHeader, "1")
+			_, _ = w.Write([]byte("logger:\n  level: info\n"))
+		},
+		// 立即返回相同的版本
+		"same version": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(ConfigVersionHeader, "1")
+			_, _ = w.Write([]byte("logger:\n  level: info\n"))
+		},
+	}
+	for name, h := range handlers {
+		var mu sync.Mutex
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests++
+			mu.Unlock()
+			h(w, r)
+		}))
+
+		src := NewHTTPSource(server.URL, WithRetryDelay(50*time.Millisecond))
+		if _, err := src.Load(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		changes := 0
+		_ = src.Watch(ctx, func() { changes++ })
+		cancel()
+		server.Close()
+
+		mu.Lock()
+		n := requests
+		mu.Unlock()
+		if n > 10 || changes != 0 {
+			t.Errorf("%s: %d requests, %d changes in 300ms with 50ms retry delay", name, n, changes)
+		}
+	}
+}
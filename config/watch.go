@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay 文件变更后等待的时间，编辑器保存文件时通常会连续触发多个事件，合并为一次重新加载
+const reloadDelay = 100 * time.Millisecond
+
+// watcher 监听配置文件变更的状态
+type watcher struct {
+	wmu    sync.Mutex
+	fsw    *fsnotify.Watcher
+	cancel context.CancelFunc
+}
+
+// Watch 监听配置文件所在的目录，配置文件（包括之后新建的环境配置及本地配置文件）变更或其符号链接指向新的文件时调用 Reload
+// AddSource 添加的配置来源同时通过其 Watch 方法监听变更
+// 重新加载或监听失败时调用 onError，当前配置保持不变；重复调用时直接返回
+func (c *Config) Watch(onError func(error)) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	sources, files := c.addedSources(), c.baseFiles()
+	if c.cancel != nil || (len(files) == 0 && len(sources) == 0) {
+		return nil
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	dirs := make(map[string]bool)
+	watched := c.Files()
+	for _, file := range files {
+		watched = append(watched, c.loadPath+file)
+	}
+	targets := resolveTargets(watched)
+	for _, file := range watched {
+		dir := filepath.Dir(file)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err := fsw.Add(dir); err != nil {
+			fsw.Close()
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 1)
+	changed := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+	for _, src := range sources {
+		go func(src Source) {
+			if err := src.Watch(ctx, changed); err != nil && onError != nil {
+				onError(fmt.Errorf("配置来源 %s 监听失败 err: %v", src.Name(), err))
+			}
+		}(src)
+	}
+
+	c.fsw, c.cancel = fsw, cancel
+	go c.watch(ctx, fsw, targets, changes, onError)
+	return nil
+}
+
+// StopWatch 停止监听配置文件及配置来源的变更
+func (c *Config) StopWatch() {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+		c.fsw.Close()
+		c.fsw, c.cancel = nil, nil
+	}
+}
+
+// watch 处理文件变更事件，配置文件本身变更，或配置文件的符号链接指向了新的文件时重新加载
+// Kubernetes 挂载的 ConfigMap 及 Secret 通过替换目录中的 ..data 符号链接更新，不会产生配置文件本身的事件
+func (c *Config) watch(ctx context.Context, fsw *fsnotify.Watcher, targets map[string]string, changes <-chan struct{}, onError func(error)) {
+	var timer <-chan time.Time
+	for {
+		select {
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			if c.isConfigFile(event.Name) && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				timer = time.After(reloadDelay)
+			} else if retarget(targets) {
+				timer = time.After(reloadDelay)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			if onError != nil {
+				onError(err)
+			}
+		case <-changes:
+			timer = time.After(reloadDelay)
+		case <-timer:
+			timer = nil
+			if err := c.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// isConfigFile 判断文件是否为当前配置会读取的文件，即加载的配置文件及 app.*.yaml 形式的环境配置、本地配置文件
+// 运行环境可能随配置变更，因此不限定具体的环境名称
+func (c *Config) isConfigFile(name string) bool {
+	base := filepath.Base(name)
+	for _, file := range c.baseFiles() {
+		ext := filepath.Ext(file)
+		prefix := strings.TrimSuffix(filepath.Base(file), ext) + "."
+		if base == filepath.Base(file) || (strings.HasPrefix(base, prefix) && strings.HasSuffix(base, ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveTargets 返回每个配置文件经过符号链接解析后的实际路径，文件不存在时为空
+func resolveTargets(files []string) map[string]string {
+	targets := make(map[string]string, len(files))
+	for _, file := range files {
+		targets[file], _ = filepath.EvalSymlinks(file)
+	}
+	return targets
+}
+
+// retarget 重新解析配置文件的实际路径，任意文件指向的实际文件发生变化时更新 targets 并返回 true
+func retarget(targets map[string]string) bool {
+	changed := false
+	for file, target := range targets {
+		current, _ := filepath.EvalSymlinks(file)
+		if current != target {
+			targets[file] = current
+			changed = true
+		}
+	}
+	return changed
+}
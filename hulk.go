@@ -15,48 +15,47 @@ const (
 
 // NewGRPCApplication 创建一个可以基于 gRPC 框架提供 RPC 接口服务的应用实例
 // 基于 gRPC 不仅可以提供 gRPC 默认的 RPC 接口服务，还可以通过配置开启同时提供 HTTP 接口服务
-func NewGRPCApplication(name string, opts ...boot.GRPCAppOption) *boot.GRPCApplication {
+// opts 可以同时包含 boot.WithGateway 等 gRPC 应用的选项及 boot.WithConfigPath 等通用选项
+func NewGRPCApplication(name string, opts ...boot.GRPCOption) *boot.GRPCApplication {
 	app := &boot.GRPCApplication{
 		Application: boot.Application{
 			Name:    name,
 			Type:    APP_TYPE_GRPC,
 			LogPath: logger.DefaultLogSavePath,
 			Config:  config.NewConfig(),
+
+			Scheduler: boot.NewScheduler(),
 		},
-		GRPCServer:      boot.NewGRPCServer(),
-		GatewayServeMux: boot.NewGateway(),
 	}
+	app.GatewayServeMux = app.NewGateway()
 
-	for _, opt := range opts {
-		opt(app)
-	}
+	boot.ApplyGRPCOptions(app, opts...)
+	app.GRPCServer = app.DefaultGRPCServer()
 
-	if app.Log == nil {
-		// 如果未额外设置日志采集器，则使用内置的 ilog 实现
-		app.Log = logger.Logger()
-	}
+	// 如果未额外设置日志采集器，则根据 LogPath 使用内置的日志实现
+	app.SetupLogger()
 
 	return app
 }
 
 // NewGinApplication 创建一个可以基于 Gin 框架提供 HTTP 接口服务的应用实例
-func NewGinApplication(name string, opts ...boot.GinAppOption) *boot.GinApplication {
+// opts 可以包含 boot.WithConfigPath 等通用选项
+func NewGinApplication(name string, opts ...boot.GinOption) *boot.GinApplication {
 	app := &boot.GinApplication{
 		Application: boot.Application{
 			Name:    name,
 			Type:    APP_TYPE_GIN,
 			LogPath: logger.DefaultLogSavePath,
+			Config:  config.NewConfig(),
+
+			Scheduler: boot.NewScheduler(),
 		},
 	}
 
-	for _, opt := range opts {
-		opt(app)
-	}
+	boot.ApplyGinOptions(app, opts...)
 
-	if app.Log == nil {
-		// 如果未额外设置日志采集器，则使用内置的 ilog 实现
-		app.Log = logger.Logger()
-	}
+	// 如果未额外设置日志采集器，则根据 LogPath 使用内置的日志实现
+	app.SetupLogger()
 
 	return app
 }
@@ -0,0 +1,46 @@
+package hulk
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGeneratedCommand 构建与生成项目相同的入口，并以 hulc-tools new 提示的方式启动
+func TestGeneratedCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("需要构建可执行文件")
+	}
+	dir, err := ioutil.TempDir("", "hulk-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bin := filepath.Join(dir, "server")
+	if out, err := exec.Command("go", "build", "-o", bin, "./testdata/server").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	configs := filepath.Join(dir, "configs")
+	if err := os.Mkdir(configs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(configs, "app.yaml"), []byte("grpc:\n  port: 9000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(bin, "-conf", configs, "-env", "test", "-set", "grpc.port=9100", "-print-config")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %v\n%s", strings.Join(cmd.Args, " "), err, out)
+	}
+	for _, want := range []string{"9100", "override"} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("output should contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package hulktest 提供在内存中运行 GRPCApplication 的测试工具
+// 使用 bufconn 代替真实端口，配置直接来自 map，所有资源通过 t.Cleanup 自动回收，测试之间可以并行执行
+package hulktest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	hulk "github.com/liuyuanxiang/go-hulc"
+	"github.com/liuyuanxiang/go-hulc/boot"
+	"github.com/liuyuanxiang/go-hulc/config"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// Server 一个运行在内存中的 GRPCApplication 及其客户端连接
+type Server struct {
+	App *boot.GRPCApplication
+
+	// Conn 已连接到应用 gRPC 服务的客户端连接
+	Conn *grpc.ClientConn
+	// Gateway 应用 HTTP 接口服务的 Handler，可以配合 httptest 使用
+	Gateway http.Handler
+	// Log 应用使用的内存日志记录器
+	Log *MemoryLogger
+}
+
+type options struct {
+	name     string
+	config   map[string]interface{}
+	appOpts  []boot.GRPCOption
+	register func(*grpc.Server)
+	gateway  func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error
+}
+
+type Option func(*options)
+
+// WithName 设置应用名称，默认为 hulktest
+func WithName(name string) Option {
+	return func(o *options) { o.name = name }
+}
+
+// WithConfig 设置应用使用的配置内容，键名支持嵌套 map，与 app.yaml 中的结构一致
+func WithConfig(m map[string]interface{}) Option {
+	return func(o *options) { o.config = m }
+}
+
+// WithAppOptions 额外设置创建应用时使用的选项
+func WithAppOptions(opts ...boot.GRPCOption) Option {
+	return func(o *options) { o.appOpts = append(o.appOpts, opts...) }
+}
+
+// WithService 设置 gRPC 服务的注册函数，等同于 GRPCApplication.RegisterGRPCServer
+func WithService(register func(*grpc.Server)) Option {
+	return func(o *options) { o.register = register }
+}
+
+// WithGateway 开启 Gateway 并设置其注册函数
+// 注册函数会拿到连接到内存 gRPC 服务的客户端连接，应使用 RegisterXXXHandler(ctx, mux, conn) 完成注册
+func WithGateway(register func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error) Option {
+	return func(o *options) { o.gateway = register }
+}
+
+// Start 创建并在 bufconn 上运行一个 GRPCApplication，返回时客户端连接已就绪
+// 测试结束时会自动关闭客户端连接并优雅退出应用
+func Start(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	o := &options{name: "hulktest"}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	lis := bufconn.Listen(bufSize)
+	dialer := func(context.Context, string) (net.Conn, error) { return lis.Dial() }
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("hulktest: dial bufconn err: %v", err)
+	}
+
+	lg := NewMemoryLogger()
+	app := hulk.NewGRPCApplication(o.name, append([]boot.GRPCOption{boot.WithLogger(lg)}, o.appOpts...)...)
+	app.Config = config.NewConfigFromMap(o.config)
+	app.RegisterGRPCServer = o.register
+	if o.gateway != nil {
+		app.OpenGateway()
+		app.RegisterGateway = func(ctx context.Context, mux *runtime.ServeMux) error {
+			return o.gateway(ctx, mux, conn)
+		}
+	}
+
+	if err := app.Setup(); err != nil {
+		conn.Close()
+		t.Fatalf("hulktest: %v", err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- app.GRPCServer.Serve(lis) }()
+
+	t.Cleanup(func() {
+		conn.Close()
+		app.Stop()
+		if err := <-serveErr; err != nil && err != grpc.ErrServerStopped {
+			t.Errorf("hulktest: gRPCServer.Serve err: %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if !waitReady(ctx, conn) {
+		t.Fatalf("hulktest: gRPC 客户端连接未能就绪")
+	}
+
+	return &Server{
+		App:     app,
+		Conn:    conn,
+		Gateway: app.HTTPHandler(),
+		Log:     lg,
+	}
+}
+
+// waitReady 等待客户端连接进入 Ready 状态
+func waitReady(ctx context.Context, conn *grpc.ClientConn) bool {
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return true
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package hulktest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/liuyuanxiang/go-hulc/boot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestStart(t *testing.T) {
+	t.Parallel()
+
+	srv := Start(t,
+		WithConfig(map[string]interface{}{
+			"app": map[string]interface{}{"env": "test"},
+		}),
+		WithService(func(s *grpc.Server) {
+			healthpb.RegisterHealthServer(s, health.NewServer())
+		}),
+		WithGateway(func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+			client := healthpb.NewHealthClient(conn)
+			return mux.HandlePath(http.MethodGet, "/healthz", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				resp, err := client.Check(r.Context(), &healthpb.HealthCheckRequest{})
+				if err != nil {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(resp.Status.String()))
+			})
+		}),
+	)
+
+	if srv.App.Config.GetString("app.env") != "test" {
+		t.Fatalf("config not loaded from map")
+	}
+
+	resp, err := healthpb.NewHealthClient(srv.Conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("status = %v, want SERVING", resp.Status)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.Gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "SERVING" {
+		t.Fatalf("gateway response = %d %q", rec.Code, rec.Body.String())
+	}
+
+	srv.App.Log.Warn("hello", "hulktest")
+	if !srv.Log.Contains("WARN", "hello hulktest") {
+		t.Fatalf("log entry not captured:\n%s", srv.Log)
+	}
+}
+
+func TestStartWithGinMounted(t *testing.T) {
+	t.Parallel()
+
+	srv := Start(t,
+		WithConfig(map[string]interface{}{
+			"http": map[string]interface{}{
+				"gin": map[string]interface{}{"prefixes": []interface{}{"/webhooks"}},
+			},
+		}),
+		WithAppOptions(boot.WithGin(func(e *gin.Engine) error {
+			e.POST("/webhooks/github", func(c *gin.Context) { c.String(http.StatusAccepted, "queued") })
+			return nil
+		}, "/ignored")),
+		WithGateway(func(ctx context.Context, mux *runtime.ServeMux, _ *grpc.ClientConn) error {
+			return mux.HandlePath(http.MethodGet, "/v1/ping", func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+				w.Write([]byte("pong"))
+			})
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	srv.Gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhooks/github", nil))
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "queued" || rec.Header().Get(boot.TraceIDHeader) == "" {
+		t.Fatalf("gin response = %d %q", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/ping", nil))
+	if rec.Body.String() != "pong" {
+		t.Fatalf("gateway response = %d %q", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,70 @@
+package hulktest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Entry 内存日志记录器中的一条日志
+type Entry struct {
+	Level   string
+	Message string
+}
+
+// MemoryLogger 将日志保存在内存中的 LogInterface 实现，便于测试中对日志输出进行断言
+// Fatal 只会记录日志，不会退出进程
+type MemoryLogger struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewMemoryLogger 返回一个空的内存日志记录器
+func NewMemoryLogger() *MemoryLogger {
+	return &MemoryLogger{}
+}
+
+func (l *MemoryLogger) Debug(v ...interface{}) { l.record("DEBUG", v...) }
+func (l *MemoryLogger) Info(v ...interface{})  { l.record("INFO", v...) }
+func (l *MemoryLogger) Warn(v ...interface{})  { l.record("WARN", v...) }
+func (l *MemoryLogger) Error(v ...interface{}) { l.record("ERROR", v...) }
+func (l *MemoryLogger) Fatal(v ...interface{}) { l.record("FATAL", v...) }
+
+func (l *MemoryLogger) record(level string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, Entry{Level: level, Message: strings.TrimSuffix(fmt.Sprintln(v...), "\n")})
+}
+
+// Entries 返回目前为止记录的所有日志
+func (l *MemoryLogger) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// Contains 判断是否存在指定级别且包含 substr 的日志，level 为空时匹配所有级别
+func (l *MemoryLogger) Contains(level, substr string) bool {
+	for _, e := range l.Entries() {
+		if (level == "" || e.Level == level) && strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// String 按记录顺序返回所有日志内容，便于在断言失败时输出
+func (l *MemoryLogger) String() string {
+	var b strings.Builder
+	for _, e := range l.Entries() {
+		fmt.Fprintf(&b, "[%s] %s\n", e.Level, e.Message)
+	}
+	return b.String()
+}
+
+// Reset 清空已记录的日志
+func (l *MemoryLogger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = nil
+}
@@ -0,0 +1,73 @@
+package logger
+
+import "context"
+
+type (
+	traceIDKey   struct{}
+	spanIDKey    struct{}
+	requestIDKey struct{}
+	userIDKey    struct{}
+)
+
+// ContextWithTraceID 返回携带链路追踪 ID 的上下文
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext 返回上下文中携带的链路追踪 ID，不存在时返回空字符串
+func TraceIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey{}).(string)
+	return id
+}
+
+// ContextWithSpanID 返回携带链路追踪 Span ID 的上下文
+func ContextWithSpanID(ctx context.Context, spanID string) context.Context {
+	return context.WithValue(ctx, spanIDKey{}, spanID)
+}
+
+// SpanIDFromContext 返回上下文中携带的 Span ID，不存在时返回空字符串
+func SpanIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(spanIDKey{}).(string)
+	return id
+}
+
+// ContextWithRequestID 返回携带请求 ID 的上下文
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 返回上下文中携带的请求 ID，不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithUserID 返回携带当前用户 ID 的上下文
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext 返回上下文中携带的用户 ID，不存在时返回空字符串
+func UserIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey{}).(string)
+	return id
+}
+
+// contextFields 返回上下文中携带的链路追踪 ID、Span ID、请求 ID 及用户 ID，未携带的不会出现在结果中
+func contextFields(ctx context.Context) Fields {
+	fields := Fields{}
+	if ctx == nil {
+		return fields
+	}
+	for key, value := range map[string]string{
+		TraceIDKey:   TraceIDFromContext(ctx),
+		SpanIDKey:    SpanIDFromContext(ctx),
+		RequestIDKey: RequestIDFromContext(ctx),
+		UserIDKey:    UserIDFromContext(ctx),
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
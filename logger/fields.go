@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithContext 添加的结构化字段名称
+const (
+	TraceIDKey   = "trace_id"
+	SpanIDKey    = "span_id"
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+)
+
+// badKey kv 参数的数量为奇数或键名为空时，多出的值使用的字段名称
+const badKey = "!BADKEY"
+
+// Fields 日志的结构化字段
+type Fields map[string]interface{}
+
+// StructuredLogger 支持结构化字段的日志处理器
+// With 及 WithContext 返回携带字段的子日志处理器，子日志处理器记录的每一条日志都包含这些字段，原日志处理器不受影响
+// Infow 等方法的 kv 依次为字段名称及取值，如 Infow("订单创建成功", "order_id", id, "amount", amount)
+type StructuredLogger interface {
+	LogInterface
+
+	With(key string, value interface{}) StructuredLogger
+	WithContext(ctx context.Context) StructuredLogger
+
+	Debugw(msg string, kv ...interface{})
+	Infow(msg string, kv ...interface{})
+	Warnw(msg string, kv ...interface{})
+	Errorw(msg string, kv ...interface{})
+	Fatalw(msg string, kv ...interface{})
+}
+
+// Structured 返回支持结构化字段的日志处理器
+// lg 未实现 StructuredLogger 时进行包装，字段以 key=value 的形式追加在日志内容之后，兼容自定义的 LogInterface 实现
+func Structured(lg LogInterface) StructuredLogger {
+	if s, ok := lg.(StructuredLogger); ok {
+		return s
+	}
+	return &structuredLogger{lg: lg}
+}
+
+// With 返回 logger 包默认日志处理器携带字段的子日志处理器
+func With(key string, value interface{}) StructuredLogger {
+	return Structured(Logger()).With(key, value)
+}
+
+// WithContext 返回 logger 包默认日志处理器携带上下文中链路追踪 ID 等信息的子日志处理器
+func WithContext(ctx context.Context) StructuredLogger { return Structured(Logger()).WithContext(ctx) }
+
+// Debugw 记录带有结构化字段的调试日志
+func Debugw(msg string, kv ...interface{}) { Structured(Logger()).Debugw(msg, kv...) }
+
+// Infow 记录带有结构化字段的常规日志
+func Infow(msg string, kv ...interface{}) { Structured(Logger()).Infow(msg, kv...) }
+
+// Warnw 记录带有结构化字段的警告日志
+func Warnw(msg string, kv ...interface{}) { Structured(Logger()).Warnw(msg, kv...) }
+
+// Errorw 记录带有结构化字段的错误日志
+func Errorw(msg string, kv ...interface{}) { Structured(Logger()).Errorw(msg, kv...) }
+
+// Fatalw 记录带有结构化字段的错误日志后退出进程
+func Fatalw(msg string, kv ...interface{}) { Structured(Logger()).Fatalw(msg, kv...) }
+
+// fieldsOf 将 kv 参数转换为字段，非字符串的键名使用 fmt.Sprint 转换
+func fieldsOf(kv []interface{}) Fields {
+	fields := make(Fields, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields[badKey] = kv[i]
+			break
+		}
+		key := fmt.Sprint(kv[i])
+		if key == "" {
+			key = badKey
+		}
+		fields[key] = kv[i+1]
+	}
+	return fields
+}
+
+// with 返回合并了 other 的新字段，同名字段以 other 为准
+func (f Fields) with(other Fields) Fields {
+	out := make(Fields, len(f)+len(other))
+	for k, v := range f {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+	return out
+}
+
+// String 返回按名称排序的 key=value 形式的字段，每个字段前有一个空格，便于追加在日志内容之后
+func (f Fields) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		v := fmt.Sprint(f[k])
+		if strings.ContainsAny(v, " =\"\n") {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, v)
+	}
+	return b.String()
+}
+
+// structuredLogger 为未实现 StructuredLogger 的日志处理器提供结构化字段的支持
+type structuredLogger struct {
+	lg     LogInterface
+	fields Fields
+}
+
+func (s *structuredLogger) With(key string, value interface{}) StructuredLogger {
+	return &structuredLogger{lg: s.lg, fields: s.fields.with(Fields{key: value})}
+}
+
+func (s *structuredLogger) WithContext(ctx context.Context) StructuredLogger {
+	return &structuredLogger{lg: s.lg, fields: s.fields.with(contextFields(ctx))}
+}
+
+func (s *structuredLogger) Debug(v ...interface{}) { s.lg.Debug(s.args(v)...) }
+func (s *structuredLogger) Info(v ...interface{})  { s.lg.Info(s.args(v)...) }
+func (s *structuredLogger) Warn(v ...interface{})  { s.lg.Warn(s.args(v)...) }
+func (s *structuredLogger) Error(v ...interface{}) { s.lg.Error(s.args(v)...) }
+func (s *structuredLogger) Fatal(v ...interface{}) { s.lg.Fatal(s.args(v)...) }
+
+func (s *structuredLogger) Debugw(msg string, kv ...interface{}) {
+	if lv, ok := s.lg.(Leveler); ok && !lv.Enabled(DebugLevel) {
+		return
+	}
+	s.lg.Debug(s.line(msg, kv))
+}
+
+func (s *structuredLogger) Infow(msg string, kv ...interface{})  { s.lg.Info(s.line(msg, kv)) }
+func (s *structuredLogger) Warnw(msg string, kv ...interface{})  { s.lg.Warn(s.line(msg, kv)) }
+func (s *structuredLogger) Errorw(msg string, kv ...interface{}) { s.lg.Error(s.line(msg, kv)) }
+func (s *structuredLogger) Fatalw(msg string, kv ...interface{}) { s.lg.Fatal(s.line(msg, kv)) }
+
+// args 未携带字段时原样传递日志内容，否则将字段追加在日志内容之后
+func (s *structuredLogger) args(v []interface{}) []interface{} {
+	if len(s.fields) == 0 {
+		return v
+	}
+	return []interface{}{fmt.Sprint(v...) + s.fields.String()}
+}
+
+func (s *structuredLogger) line(msg string, kv []interface{}) string {
+	return msg + s.fields.with(fieldsOf(kv)).String()
+}
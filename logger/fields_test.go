@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestILogStructuredFields(t *testing.T) {
+	dir := tempDir(t)
+	lg := NewILog(SetSavePath(dir))
+
+	ctx := ContextWithTraceID(context.Background(), "t-1")
+	ctx = ContextWithSpanID(ctx, "s-1")
+	ctx = ContextWithUserID(ctx, "u-1")
+	child := lg.WithContext(ctx).With("order_id", 42)
+	child.Infow("订单创建成功", "amount", 9.5)
+	lg.Info("plain")
+
+	lines := strings.Split(strings.TrimSpace(readFile(t, filepath.Join(dir, DefaultLogSaveName+"_info.log"))), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q", lines)
+	}
+	var rec infoLog
+	if err := json.Unmarshal([]byte(lines[0][strings.Index(lines[0], "{"):]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.TraceID != "t-1" || rec.SpanID != "s-1" || rec.Message != "订单创建成功" {
+		t.Fatalf("record = %+v", rec)
+	}
+	if rec.Fields[UserIDKey] != "u-1" || rec.Fields["order_id"] != float64(42) || rec.Fields["amount"] != 9.5 || rec.Fields[TraceIDKey] != nil {
+		t.Fatalf("fields = %v", rec.Fields)
+	}
+	if strings.Contains(lines[1], "Fields") || strings.Contains(lines[1], "order_id") {
+		t.Fatalf("parent logger carries child fields: %s", lines[1])
+	}
+}
+
+func TestDefaultLoggerStructuredFields(t *testing.T) {
+	dir := tempDir(t)
+	lg := newDefaultLogger(dir)
+	child := lg.With("user", "bob")
+	child.Infow("login", "ip", "10.0.0.1", "ok")
+	child.Info("plain", 1)
+	child.Debugw("hidden")
+
+	out := readFile(t, filepath.Join(dir, DefaultLogSaveName+".log"))
+	for _, want := range []string{"[INFO] login !BADKEY=ok ip=10.0.0.1 user=bob", "[INFO] [plain 1] user=bob"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("log = %q, want %q", out, want)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("debug entry written when disabled:\n%s", out)
+	}
+}
+
+type recordLogger struct{ lines []string }
+
+func (r *recordLogger) Debug(v ...interface{}) { r.lines = append(r.lines, fmt.Sprint(v...)) }
+func (r *recordLogger) Info(v ...interface{})  { r.lines = append(r.lines, fmt.Sprint(v...)) }
+func (r *recordLogger) Warn(v ...interface{})  { r.lines = append(r.lines, fmt.Sprint(v...)) }
+func (r *recordLogger) Error(v ...interface{}) { r.lines = append(r.lines, fmt.Sprint(v...)) }
+func (r *recordLogger) Fatal(v ...interface{}) { r.lines = append(r.lines, fmt.Sprint(v...)) }
+
+func TestStructuredWrapsLogInterface(t *testing.T) {
+	rec := &recordLogger{}
+	lg := Structured(rec)
+	lg.Info("plain")
+	ctx := ContextWithRequestID(context.Background(), "r-1")
+	lg.WithContext(ctx).Warnw("slow query", "took", "1.5 s")
+
+	want := []string{"plain", `slow query request_id=r-1 took="1.5 s"`}
+	if len(rec.lines) != 2 || rec.lines[0] != want[0] || rec.lines[1] != want[1] {
+		t.Fatalf("lines = %q, want %q", rec.lines, want)
+	}
+	if Structured(lg) != lg {
+		t.Fatal("Structured should return loggers that already support fields")
+	}
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"log"
 	"os"
+	"path/filepath"
 )
 
 var (
@@ -15,7 +16,7 @@ func openLogFile(filePath string) *os.File {
 	_, err := os.Stat(filePath)
 	switch {
 	case os.IsNotExist(err):
-		mkDir()
+		mkDir(filepath.Dir(filePath))
 	case os.IsPermission(err):
 		log.Fatalf("Permission :%v", err)
 	}
@@ -28,9 +29,8 @@ func openLogFile(filePath string) *os.File {
 	return handle
 }
 
-func mkDir() {
-	dir, _ := os.Getwd()
-	err := os.MkdirAll(dir+"/"+DefaultLogSavePath, os.ModePerm)
+func mkDir(dir string) {
+	err := os.MkdirAll(dir, os.ModePerm)
 	if err != nil {
 		panic(err)
 	}
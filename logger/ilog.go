@@ -1,15 +1,21 @@
 package logger
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 )
 
 type ILog struct {
+	*atomicLevel
+
 	savePath string
 	saveName string
 	isDev    bool
+	fields   Fields
 
 	infoLog  *log.Logger
 	warnLog  *log.Logger
@@ -20,10 +26,12 @@ type ILog struct {
 }
 
 // NewILog 返回一个基于 iLog 格式的日志记录器实现
+// 默认只记录 Info 及以上级别的日志，开启 IsDev 或通过 WithLevel 设置为 DebugLevel 时记录 Debug 日志
 func NewILog(opts ...ILogOption) *ILog {
 	logger := &ILog{
-		savePath: DefaultLogSavePath,
-		saveName: DefaultLogSaveName,
+		atomicLevel: &atomicLevel{},
+		savePath:    DefaultLogSavePath,
+		saveName:    DefaultLogSaveName,
 	}
 
 	for _, o := range opts {
@@ -31,11 +39,11 @@ func NewILog(opts ...ILogOption) *ILog {
 	}
 
 	// iLog 相关的 5 个日志文件路径准备
-	infoFile := fmt.Sprintf("%s%s_info.log", logger.savePath, logger.saveName)
-	warnFile := fmt.Sprintf("%s%s_warning.log", logger.savePath, logger.saveName)
-	errorFile := fmt.Sprintf("%s%s_error.log", logger.savePath, logger.saveName)
-	reqFile := fmt.Sprintf("%s%s_request.log", logger.savePath, logger.saveName)
-	dbFile := fmt.Sprintf("%s%s_database.log", logger.savePath, logger.saveName)
+	infoFile := filepath.Join(logger.savePath, logger.saveName+"_info.log")
+	warnFile := filepath.Join(logger.savePath, logger.saveName+"_warning.log")
+	errorFile := filepath.Join(logger.savePath, logger.saveName+"_error.log")
+	reqFile := filepath.Join(logger.savePath, logger.saveName+"_request.log")
+	dbFile := filepath.Join(logger.savePath, logger.saveName+"_database.log")
 
 	// 创建 5 个对应文件的日志处理器，分别用于不同类型的日志记录
 	logger.infoLog = log.New(openLogFile(infoFile), "", log.LstdFlags)
@@ -48,35 +56,148 @@ func NewILog(opts ...ILogOption) *ILog {
 
 type ILogOption func(*ILog)
 
+// With 返回携带字段的子日志记录器，与原日志记录器共用日志文件及最低级别
+func (l *ILog) With(key string, value interface{}) StructuredLogger {
+	return l.child(Fields{key: value})
+}
+
+// WithContext 返回携带上下文中链路追踪 ID、Span ID、请求 ID 及用户 ID 的子日志记录器
+// 链路追踪 ID 及 Span ID 写入日志记录的 TraceID、SpanID，其余写入 Fields
+func (l *ILog) WithContext(ctx context.Context) StructuredLogger {
+	return l.child(contextFields(ctx))
+}
+
+func (l *ILog) child(fields Fields) *ILog {
+	c := *l
+	c.fields = l.fields.with(fields)
+	return &c
+}
+
+// Debug 记录调试日志，与 Info 日志写入同一个文件，通过日志记录的 Level 区分，未开启 Debug 级别时不会拼接日志内容
 func (l *ILog) Debug(v ...interface{}) {
-	if !l.isDev {
-		l.Info(v...)
+	if l.Enabled(DebugLevel) {
+		writeJSON(l.infoLog, newInfoLog(DebugLevel, fmt.Sprint(v...), l.meta(nil)))
 	}
 }
 
 func (l *ILog) Info(v ...interface{}) {
-	l.infoLog.Println(json.Marshal(newInfoLog(fmt.Sprint(v...))))
+	if l.Enabled(InfoLevel) {
+		writeJSON(l.infoLog, newInfoLog(InfoLevel, fmt.Sprint(v...), l.meta(nil)))
+	}
 }
 
 func (l *ILog) Warn(v ...interface{}) {
-	l.warnLog.Println(json.Marshal(newWarningLog(fmt.Sprint(v...))))
+	if l.Enabled(WarnLevel) {
+		writeJSON(l.warnLog, newWarningLog(WarnLevel, fmt.Sprint(v...), l.meta(nil)))
+	}
 }
 
 func (l *ILog) Error(v ...interface{}) {
-	l.errorLog.Println(json.Marshal(newErrorLog(fmt.Sprint(v...))))
+	if l.Enabled(ErrorLevel) {
+		writeJSON(l.errorLog, newErrorLog(ErrorLevel, fmt.Sprint(v...), l.meta(nil)))
+	}
 }
 
+// Fatal 记录错误日志后退出进程，不受最低级别的限制
 func (l *ILog) Fatal(v ...interface{}) {
-	l.errorLog.Fatalln(json.Marshal(newErrorLog(fmt.Sprint(v...))))
+	writeJSON(l.errorLog, newErrorLog(FatalLevel, fmt.Sprint(v...), l.meta(nil)))
+	os.Exit(1)
+}
+
+func (l *ILog) Debugw(msg string, kv ...interface{}) {
+	if l.Enabled(DebugLevel) {
+		writeJSON(l.infoLog, newInfoLog(DebugLevel, msg, l.meta(kv)))
+	}
+}
+
+func (l *ILog) Infow(msg string, kv ...interface{}) {
+	if l.Enabled(InfoLevel) {
+		writeJSON(l.infoLog, newInfoLog(InfoLevel, msg, l.meta(kv)))
+	}
+}
+
+func (l *ILog) Warnw(msg string, kv ...interface{}) {
+	if l.Enabled(WarnLevel) {
+		writeJSON(l.warnLog, newWarningLog(WarnLevel, msg, l.meta(kv)))
+	}
+}
+
+func (l *ILog) Errorw(msg string, kv ...interface{}) {
+	if l.Enabled(ErrorLevel) {
+		writeJSON(l.errorLog, newErrorLog(ErrorLevel, msg, l.meta(kv)))
+	}
+}
+
+func (l *ILog) Fatalw(msg string, kv ...interface{}) {
+	writeJSON(l.errorLog, newErrorLog(FatalLevel, msg, l.meta(kv)))
+	os.Exit(1)
 }
 
 func (l *ILog) DB(duration float64, v ...interface{}) {
-	l.dbLog.Println(json.Marshal(newDatabaseLog(fmt.Sprint(v...), duration)))
+	writeJSON(l.dbLog, newDatabaseLog(fmt.Sprint(v...), duration, l.meta(nil)))
+}
+
+// meta 日志记录的链路追踪信息及结构化字段
+type meta struct {
+	traceID string
+	spanID  string
+	fields  Fields
+}
+
+// meta 合并子日志记录器携带的字段及 kv，链路追踪 ID 及 Span ID 单独记录
+func (l *ILog) meta(kv []interface{}) meta {
+	if len(l.fields) == 0 && len(kv) == 0 {
+		return meta{}
+	}
+	fields := l.fields.with(fieldsOf(kv))
+	m := meta{fields: fields}
+	if id, ok := fields[TraceIDKey].(string); ok {
+		m.traceID = id
+		delete(fields, TraceIDKey)
+	}
+	if id, ok := fields[SpanIDKey].(string); ok {
+		m.spanID = id
+		delete(fields, SpanIDKey)
+	}
+	return m
 }
 
+// writeJSON 将日志记录序列化为一行 JSON 写入日志文件
+func writeJSON(lg *log.Logger, record interface{}) {
+	b, err := json.Marshal(record)
+	if err != nil {
+		lg.Println(fmt.Sprintf("%+v", record))
+		return
+	}
+	lg.Println(string(b))
+}
+
+// Request 记录一次 HTTP 请求的访问日志
+// 子日志记录器携带的字段会合并到 r.Fields 中，r 中已有的同名字段优先
+func (l *ILog) Request(r *RequestLog) {
+	if len(l.fields) > 0 {
+		rec := *r
+		rec.Fields = l.fields.with(r.Fields)
+		r = &rec
+	}
+	b, _ := json.Marshal(r)
+	l.reqLog.Println(string(b))
+}
+
+// IsDev 开启开发模式，开发模式下记录 Debug 级别的日志，可以在之后通过 WithLevel 覆盖
 func IsDev(isDev bool) ILogOption {
 	return func(lg *ILog) {
 		lg.isDev = isDev
+		if isDev {
+			lg.SetLevel(DebugLevel)
+		}
+	}
+}
+
+// WithLevel 设置记录日志的最低级别
+func WithLevel(level Level) ILogOption {
+	return func(lg *ILog) {
+		lg.SetLevel(level)
 	}
 }
 
@@ -86,11 +207,13 @@ func SetSavePath(path string) ILogOption {
 	}
 }
 
-type requestLog struct {
+// RequestLog 一次 HTTP 请求的访问日志记录
+type RequestLog struct {
 	TraceID         string
 	SpanID          string
 	Method          string
 	URL             string
+	StatusCode      int
 	Duration        float64
 	RequestTime     string
 	QueryString     string
@@ -104,17 +227,20 @@ type requestLog struct {
 	Memory          int64
 	RequestHeaders  map[string]string
 	ResponseHeaders map[string]string
+	Fields          Fields `json:",omitempty"`
 }
 
 type infoLog struct {
 	TraceID string
 	SpanID  string
+	Level   string
 	Message string
 	Time    string
+	Fields  Fields `json:",omitempty"`
 }
 
-func newInfoLog(msg string) infoLog {
-	return infoLog{Message: msg}
+func newInfoLog(level Level, msg string, m meta) infoLog {
+	return infoLog{TraceID: m.traceID, SpanID: m.spanID, Level: level.String(), Message: msg, Fields: m.fields}
 }
 
 type databaseLog struct {
@@ -124,30 +250,35 @@ type databaseLog struct {
 	Message  string
 	Duration float64
 	Time     string
+	Fields   Fields `json:",omitempty"`
 }
 
-func newDatabaseLog(msg string, duration float64) databaseLog {
-	return databaseLog{Message: msg, Duration: duration}
+func newDatabaseLog(msg string, duration float64, m meta) databaseLog {
+	return databaseLog{TraceID: m.traceID, SpanID: m.spanID, Message: msg, Duration: duration, Fields: m.fields}
 }
 
 type warningLog struct {
 	TraceID string
 	SpanID  string
+	Level   string
 	Message string
 	Time    string
+	Fields  Fields `json:",omitempty"`
 }
 
-func newWarningLog(msg string) warningLog {
-	return warningLog{Message: msg}
+func newWarningLog(level Level, msg string, m meta) warningLog {
+	return warningLog{TraceID: m.traceID, SpanID: m.spanID, Level: level.String(), Message: msg, Fields: m.fields}
 }
 
 type errorLog struct {
 	TraceID string
 	SpanID  string
+	Level   string
 	Message string
 	Time    string
+	Fields  Fields `json:",omitempty"`
 }
 
-func newErrorLog(msg string) errorLog {
-	return errorLog{Message: msg}
+func newErrorLog(level Level, msg string, m meta) errorLog {
+	return errorLog{TraceID: m.traceID, SpanID: m.spanID, Level: level.String(), Message: msg, Fields: m.fields}
 }
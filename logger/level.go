@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Level 日志级别，日志处理器只记录不低于其最低级别的日志，零值为 InfoLevel
+type Level int32
+
+const (
+	DebugLevel Level = iota - 1
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	FatalLevel
+)
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	case FatalLevel:
+		return "fatal"
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// ParseLevel 解析配置中的日志级别，支持 debug、info、warn（warning）、error、fatal，不区分大小写
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return DebugLevel, nil
+	case "info", "":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "fatal":
+		return FatalLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("无效的日志级别 %q", s)
+}
+
+// Leveler 支持按级别过滤日志的日志处理器，默认及 ilog 的实现均支持在运行时修改最低级别
+type Leveler interface {
+	SetLevel(Level)
+	GetLevel() Level
+	Enabled(Level) bool
+}
+
+// atomicLevel 可以并发读写的最低日志级别，嵌入到日志处理器中实现 Leveler
+type atomicLevel struct {
+	v int32
+}
+
+// SetLevel 设置记录日志的最低级别
+func (a *atomicLevel) SetLevel(l Level) { atomic.StoreInt32(&a.v, int32(l)) }
+
+// GetLevel 返回记录日志的最低级别
+func (a *atomicLevel) GetLevel() Level { return Level(atomic.LoadInt32(&a.v)) }
+
+// Enabled 判断指定级别的日志是否会被记录，可以在拼接开销较大的日志内容前调用
+func (a *atomicLevel) Enabled(l Level) bool { return l >= a.GetLevel() }
+
+// SetLevel 设置 logger 包默认日志处理器的最低级别，处理器不支持 Leveler 时忽略
+func SetLevel(l Level) {
+	if lv, ok := Logger().(Leveler); ok {
+		lv.SetLevel(l)
+	}
+}
+
+// Enabled 判断 logger 包默认日志处理器是否会记录指定级别的日志
+func Enabled(l Level) bool {
+	if lv, ok := Logger().(Leveler); ok {
+		return lv.Enabled(l)
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{
+		"debug": DebugLevel, "INFO": InfoLevel, "": InfoLevel, "warning": WarnLevel, " error ": ErrorLevel, "fatal": FatalLevel,
+	} {
+		if l, err := ParseLevel(s); err != nil || l != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", s, l, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) should fail")
+	}
+	if WarnLevel.String() != "warn" {
+		t.Errorf("WarnLevel.String() = %q", WarnLevel.String())
+	}
+}
+
+func TestDefaultLoggerLevel(t *testing.T) {
+	dir := tempDir(t)
+	lg := newDefaultLogger(dir)
+
+	lg.Debug("hidden")
+	lg.Info("shown")
+	lg.SetLevel(DebugLevel)
+	lg.Debug("visible")
+	lg.SetLevel(ErrorLevel)
+	lg.Warn("dropped")
+
+	out := readFile(t, filepath.Join(dir, DefaultLogSaveName+".log"))
+	if strings.Contains(out, "hidden") || strings.Contains(out, "dropped") {
+		t.Fatalf("filtered entries were written:\n%s", out)
+	}
+	if !strings.Contains(out, "[INFO] [shown]") || !strings.Contains(out, "[DEBUG] [visible]") {
+		t.Fatalf("log = %q", out)
+	}
+}
+
+func TestILogLevel(t *testing.T) {
+	dir := tempDir(t)
+	lg := NewILog(SetSavePath(dir))
+	lg.Debug("hidden")
+	lg.Info("shown")
+
+	dev := NewILog(SetSavePath(dir), IsDev(true))
+	if dev.GetLevel() != DebugLevel {
+		t.Fatalf("dev level = %v, want debug", dev.GetLevel())
+	}
+	dev.Debug("visible")
+
+	out := readFile(t, filepath.Join(dir, DefaultLogSaveName+"_info.log"))
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("debug entry written when disabled:\n%s", out)
+	}
+	if !strings.Contains(out, `"Level":"info","Message":"shown"`) || !strings.Contains(out, `"Level":"debug","Message":"visible"`) {
+		t.Fatalf("log = %q", out)
+	}
+}
@@ -1,8 +1,11 @@
 package logger
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"os"
+	"path/filepath"
+	"sync"
 )
 
 type LogInterface interface {
@@ -13,78 +16,160 @@ type LogInterface interface {
 	Fatal(...interface{})
 }
 
-type Level int
+// RequestLogInterface 可以单独记录 HTTP 请求访问日志的日志处理器，ilog 的实现会将其写入 request 日志文件
+type RequestLogInterface interface {
+	Request(*RequestLog)
+}
 
 var (
 	DefaultPrefix      = ""
 	DefaultCallerDepth = 2
 
-	lg LogInterface
+	lgMu sync.Mutex
+	lg   LogInterface
 )
 
-func init() {
-	lg = newDefaultLogger()
-}
-
 // Logger 返回当前 logger 包下正在使用的 log 实例
-// 默认使用 ilog 的实现
+// 默认实例在第一次使用时才会创建，并将日志写入 DefaultLogSavePath 目录下
 func Logger() LogInterface {
+	lgMu.Lock()
+	defer lgMu.Unlock()
+	if lg == nil {
+		lg = newDefaultLogger(DefaultLogSavePath)
+	}
 	return lg
 }
 
+// SetLogger 替换 logger 包下默认使用的 log 实例，只影响包级别的日志方法及未单独设置日志处理器的应用
+func SetLogger(l LogInterface) {
+	lgMu.Lock()
+	defer lgMu.Unlock()
+	lg = l
+}
+
 // Debug 记录调试类型日志信息
-func Debug(v ...interface{}) { lg.Debug(v...) }
+func Debug(v ...interface{}) { Logger().Debug(v...) }
 
 // Info 记录常规日志信息
-func Info(v ...interface{}) { lg.Info(v...) }
+func Info(v ...interface{}) { Logger().Info(v...) }
 
 // Warn 记录警告类型日志信息
-func Warn(v ...interface{}) { lg.Warn(v...) }
+func Warn(v ...interface{}) { Logger().Warn(v...) }
 
 // Error 记录错误类型日志信息
-func Error(v ...interface{}) { lg.Error(v...) }
+func Error(v ...interface{}) { Logger().Error(v...) }
 
 // Fatal 记录错误类型日志信息
-func Fatal(v ...interface{}) { lg.Fatal(v...) }
+func Fatal(v ...interface{}) { Logger().Fatal(v...) }
 
 // DB 记录数据库执行记录相关信息
 // func DB(duration float64, v ...interface{}) { lg.DB(duration, v...) }
 
 type defaultLogger struct {
+	*atomicLevel
+
 	savePath string
 	saveName string
+	fields   Fields
 
 	log *log.Logger
 }
 
-func newDefaultLogger() *defaultLogger {
+// NewLogger 返回一个将日志写入 savePath 目录下的默认日志记录器，默认只记录 Info 及以上级别的日志
+// 返回的日志记录器实现了 Leveler 及 StructuredLogger，可以通过 SetLevel 修改最低级别，结构化字段以 key=value 的形式记录
+func NewLogger(savePath string) LogInterface {
+	return newDefaultLogger(savePath)
+}
+
+func newDefaultLogger(savePath string) *defaultLogger {
 	lg := &defaultLogger{
-		savePath: DefaultLogSavePath,
-		saveName: DefaultLogSaveName,
+		atomicLevel: &atomicLevel{},
+		savePath:    savePath,
+		saveName:    DefaultLogSaveName,
 	}
-	file := fmt.Sprintf("%s%s.log", lg.savePath, lg.saveName)
+	file := filepath.Join(lg.savePath, lg.saveName+".log")
 	lg.log = log.New(openLogFile(file), "", log.LstdFlags)
 	return lg
 }
 
+// With 返回携带字段的子日志记录器，与原日志记录器共用日志文件及最低级别
+func (lg *defaultLogger) With(key string, value interface{}) StructuredLogger {
+	return lg.child(Fields{key: value})
+}
+
+// WithContext 返回携带上下文中链路追踪 ID、Span ID、请求 ID 及用户 ID 的子日志记录器
+func (lg *defaultLogger) WithContext(ctx context.Context) StructuredLogger {
+	return lg.child(contextFields(ctx))
+}
+
+func (lg *defaultLogger) child(fields Fields) *defaultLogger {
+	c := *lg
+	c.fields = lg.fields.with(fields)
+	return &c
+}
+
 func (lg *defaultLogger) Debug(v ...interface{}) {
-	lg.log.Println("[INFO]", v)
+	if lg.Enabled(DebugLevel) {
+		lg.output("[DEBUG]", v, lg.fields)
+	}
 }
 
 func (lg *defaultLogger) Info(v ...interface{}) {
-	lg.log.Println("[INFO]", v)
+	if lg.Enabled(InfoLevel) {
+		lg.output("[INFO]", v, lg.fields)
+	}
 }
 
 func (lg *defaultLogger) Warn(v ...interface{}) {
-	lg.log.Println("[WARN]", v)
+	if lg.Enabled(WarnLevel) {
+		lg.output("[WARN]", v, lg.fields)
+	}
 }
 
 func (lg *defaultLogger) Error(v ...interface{}) {
-	lg.log.Println("[ERROR]", v)
+	if lg.Enabled(ErrorLevel) {
+		lg.output("[ERROR]", v, lg.fields)
+	}
 }
 
+// Fatal 记录日志后退出进程，不受最低级别的限制
 func (lg *defaultLogger) Fatal(v ...interface{}) {
-	lg.log.Fatalln("[ERROR]", v)
+	lg.output("[FATAL]", v, lg.fields)
+	os.Exit(1)
+}
+
+func (lg *defaultLogger) Debugw(msg string, kv ...interface{}) {
+	if lg.Enabled(DebugLevel) {
+		lg.output("[DEBUG]", msg, lg.fields.with(fieldsOf(kv)))
+	}
+}
+
+func (lg *defaultLogger) Infow(msg string, kv ...interface{}) {
+	if lg.Enabled(InfoLevel) {
+		lg.output("[INFO]", msg, lg.fields.with(fieldsOf(kv)))
+	}
+}
+
+func (lg *defaultLogger) Warnw(msg string, kv ...interface{}) {
+	if lg.Enabled(WarnLevel) {
+		lg.output("[WARN]", msg, lg.fields.with(fieldsOf(kv)))
+	}
+}
+
+func (lg *defaultLogger) Errorw(msg string, kv ...interface{}) {
+	if lg.Enabled(ErrorLevel) {
+		lg.output("[ERROR]", msg, lg.fields.with(fieldsOf(kv)))
+	}
+}
+
+func (lg *defaultLogger) Fatalw(msg string, kv ...interface{}) {
+	lg.output("[FATAL]", msg, lg.fields.with(fieldsOf(kv)))
+	os.Exit(1)
+}
+
+// output 写入一行日志，字段以 key=value 的形式追加在日志内容之后
+func (lg *defaultLogger) output(prefix string, msg interface{}, fields Fields) {
+	lg.log.Printf("%s %v%s", prefix, msg, fields.String())
 }
 
 // func (lg *defaultLogger) DB(duration float64, v ...interface{}) {
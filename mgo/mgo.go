@@ -2,60 +2,175 @@ package mgo
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/liuyuanxiang/go-hulc/boot"
 	"gopkg.in/mgo.v2"
 )
 
-var mgoSession *mgo.Session
+var (
+	mu       sync.Mutex
+	sessions = make(map[*boot.Application]*mgo.Session)
+
+	// 已注册退出关闭函数及配置变更监听的应用，应用退出时移除，避免应用实例在退出后仍被引用
+	apps = make(map[*boot.Application]*appState)
+)
+
+// ReloadGracePeriod 配置变更后被替换的连接在关闭前保留的时间，供仍持有该连接的请求处理完成
+var ReloadGracePeriod = time.Minute
+
+// appState 应用的 Mongo 连接状态，retired 为已被替换、等待关闭的连接
+type appState struct {
+	retired map[*mgo.Session]*time.Timer
+}
 
 // MgoSession 可以根据提供的 App 应用实例，自动获取其中加载的配置信息来返回对应的 Mongo 实例
+// 每个应用实例持有各自的 Mongo 连接，连接会在应用优雅退出时自动关闭
+// 返回的连接可能在配置变更后被替换，请求处理中应通过 Copy 获取独立的连接并在处理结束后 Close，不要长期持有返回的连接
+//
+//	s := session.Copy()
+//	defer s.Close()
 func MgoSession(app *boot.Application) (*mgo.Session, error) {
-	if mgoSession != nil {
-		return mgoSession, nil
+	mu.Lock()
+	defer mu.Unlock()
+
+	if s, ok := sessions[app]; ok {
+		return s, nil
 	}
+	return newAppSession(app)
+}
+
+// ReloadMgoSession 根据应用当前的 Mongo 配置建立新的连接并返回，之后 MgoSession 返回新的连接
+// mongo.* 配置变更时会自动调用，建立连接失败时继续使用原有连接
+// 原有连接在 ReloadGracePeriod 之后关闭，已通过 Copy 获取的连接不受影响，可以继续使用至请求结束
+func ReloadMgoSession(app *boot.Application) (*mgo.Session, error) {
+	mu.Lock()
+	defer mu.Unlock()
 
-	var err error
-	mgoSession, err = newMgoSessionFromApp(app)
+	old, ok := sessions[app]
+	s, err := newAppSession(app)
 	if err != nil {
 		return nil, err
 	}
-	return mgoSession, nil
+	if ok {
+		apps[app].retire(old)
+	}
+	return s, nil
 }
 
-// ReloadMgoSession 可以重新加载 Mongo 实例并返回
-// 如果 App 中的 Mongo 配置信息变更，希望关闭旧的链接并建立新的链接返回时，可以调用该方法
-// 该方法调用后，原本的链接将会失效不可用
-func ReloadMgoSession(app *boot.Application) (*mgo.Session, error) {
-	if mgoSession != nil {
-		// 关闭旧链接
-		mgoSession.Close()
+// retire 在 ReloadGracePeriod 之后关闭被替换的连接，调用方需持有 mu
+func (st *appState) retire(s *mgo.Session) {
+	st.retired[s] = time.AfterFunc(ReloadGracePeriod, func() {
+		mu.Lock()
+		_, ok := st.retired[s]
+		delete(st.retired, s)
+		mu.Unlock()
+		if ok {
+			s.Close()
+		}
+	})
+}
+
+// CloseMgoSession 关闭应用持有的所有 Mongo 连接，包括等待关闭的被替换的连接，应用优雅退出时会自动调用
+func CloseMgoSession(app *boot.Application) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if s, ok := sessions[app]; ok {
+		s.Close()
+		delete(sessions, app)
+	}
+	if st, ok := apps[app]; ok {
+		for s, timer := range st.retired {
+			timer.Stop()
+			s.Close()
+			delete(st.retired, s)
+		}
 	}
+}
 
-	var err error
-	mgoSession, err = newMgoSessionFromApp(app)
+// newAppSession 为应用创建 Mongo 连接，第一次创建时注册应用退出时的关闭函数及配置变更的监听函数，调用方需持有 mu
+func newAppSession(app *boot.Application) (*mgo.Session, error) {
+	s, err := newMgoSessionFromApp(app)
 	if err != nil {
 		return nil, err
 	}
-	return mgoSession, nil
+
+	if _, ok := apps[app]; !ok {
+		st := &appState{retired: make(map[*mgo.Session]*time.Timer)}
+		apps[app] = st
+		app.OnStop(func() {
+			CloseMgoSession(app)
+			mu.Lock()
+			delete(apps, app)
+			mu.Unlock()
+		})
+		watchConfig(app, st)
+	}
+	sessions[app] = s
+	return s, nil
+}
+
+// Config MongoDB 的连接配置，对应配置文件中的 mongo
+//
+//	mongo:
+//	  host: 127.0.0.1
+//	  port: 27017
+//	  username: root
+//	  password: ${MONGO_PASSWORD}
+//	  timeout: 10s
+//
+// password 也可以使用 config.Encrypt 生成的 ENC(...) 加密值或 secret:// 引用
+// timeout 兼容旧配置中不带单位的整数，按秒解析，如 timeout: 300 等同于 300s
+type Config struct {
+	Host     string        `mapstructure:"host" default:"127.0.0.1" validate:"required"`
+	Port     int           `mapstructure:"port" default:"27017" validate:"min=1,max=65535"`
+	Username string        `mapstructure:"username"`
+	Password string        `mapstructure:"password"`
+	Timeout  time.Duration `mapstructure:"timeout" default:"10s" validate:"gt=0"`
 }
 
 func newMgoSessionFromApp(app *boot.Application) (*mgo.Session, error) {
-	host, _ := app.Config.GetDefault("mongo.host", "127.0.0.1").(string)
-	port, _ := app.Config.GetDefault("mongo.port", 27017).(int64)
-	username, _ := app.Config.GetDefault("mongo.username", "").(string)
-	password, _ := app.Config.GetDefault("mongo.password", "").(string)
-	timeout, _ := app.Config.GetDefault("mongo.timeout", 300).(int64)
-	if host == "" || port == 0 {
-		return nil, fmt.Errorf("MongoDB 所需 host port 配置缺失")
+	var c Config
+	if err := app.Config.Bind("mongo", &c); err != nil {
+		return nil, fmt.Errorf("MongoDB 配置异常 err: %v", err)
 	}
 
-	url := fmt.Sprintf("mongodb://%s:%s@%s:%d", username, password, host, port)
-	m, err := mgo.DialWithTimeout(url, time.Duration(timeout)*time.Microsecond)
+	m, err := mgo.DialWithInfo(&mgo.DialInfo{
+		Addrs:    []string{net.JoinHostPort(c.Host, strconv.Itoa(c.Port))},
+		Username: c.Username,
+		Password: c.Password,
+		Timeout:  c.Timeout,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	return m, nil
 }
+
+// Require 声明应用依赖 MongoDB，需在应用 Init 之前调用，mongo 配置会与其他配置一起在启动时校验
+func Require(app *boot.Application) {
+	app.Config.Require("mongo", &Config{})
+}
+
+// watchConfig 在配置重新加载前校验新的 mongo 配置，mongo.* 变更后重新建立连接
+// 配置的监听函数无法移除，应用退出后 st 不再是当前的状态，此前注册的监听函数不再生效，重新启动时也不会重复触发
+func watchConfig(app *boot.Application, st *appState) {
+	Require(app)
+	app.Config.OnChange("mongo", func(_, _ interface{}) {
+		mu.Lock()
+		_, ok := sessions[app]
+		current := ok && apps[app] == st
+		mu.Unlock()
+		if !current {
+			return
+		}
+		if _, err := ReloadMgoSession(app); err != nil {
+			app.Log.Error("MongoDB 配置变更后重新连接失败 err:", err)
+		}
+	})
+}
@@ -0,0 +1,17 @@
+// server 与 hulc-tools new 生成的项目入口一致，用于测试生成项目提示的启动命令
+package main
+
+import (
+	"fmt"
+	"os"
+
+	hulk "github.com/liuyuanxiang/go-hulc"
+)
+
+func main() {
+	app := hulk.NewGRPCApplication("server")
+	if err := app.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
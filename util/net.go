@@ -0,0 +1,59 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const unixScheme = "unix://"
+
+// ParseListenAddr 将配置中的监听地址解析为 net.Listen 所需的 network 与 address
+// 支持 host:port、[::1]:port、:0 等 TCP 地址，以及 unix:///path/to.sock 形式的 Unix Domain Socket
+func ParseListenAddr(addr string) (network, address string) {
+	if strings.HasPrefix(addr, unixScheme) {
+		return "unix", strings.TrimPrefix(addr, unixScheme)
+	}
+	return "tcp", addr
+}
+
+// Listen 根据配置中的监听地址创建 Listener
+// Unix Domain Socket 在监听前会清理上一次进程异常退出遗留的 socket 文件，Listener 关闭时 socket 文件会被自动删除
+// socket 文件仍有进程在监听时返回错误，不会抢占正在运行的实例
+func Listen(addr string) (net.Listener, error) {
+	network, address := ParseListenAddr(addr)
+	if network == "unix" {
+		if err := removeStaleSocket(address); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen(network, address)
+}
+
+func removeStaleSocket(path string) error {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s 已存在且不是 socket 文件", path)
+	}
+
+	// 只有连接被拒绝时才说明 socket 文件已无进程监听
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s 正在被其他进程监听", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("%s 检查 socket 文件是否可用失败 err: %v", path, err)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseListenAddr(t *testing.T) {
+	cases := []struct{ addr, network, address string }{
+		{":0", "tcp", ":0"},
+		{"127.0.0.1:9000", "tcp", "127.0.0.1:9000"},
+		{"[::1]:9000", "tcp", "[::1]:9000"},
+		{"unix:///tmp/app.sock", "unix", "/tmp/app.sock"},
+	}
+	for _, c := range cases {
+		network, address := ParseListenAddr(c.addr)
+		if network != c.network || address != c.address {
+			t.Errorf("ParseListenAddr(%q) = %q %q, want %q %q", c.addr, network, address, c.network, c.address)
+		}
+	}
+}
+
+func TestListenRandomPort(t *testing.T) {
+	lis, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	if lis.Addr().(*net.TCPAddr).Port == 0 {
+		t.Fatal("expected a port to be assigned")
+	}
+}
+
+func TestListenUnixRemovesStaleSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.sock")
+
+	// 模拟进程异常退出后遗留的 socket 文件
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	lis, err := Listen("unix://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen("unix://" + path); err == nil {
+		t.Fatal("expected a socket in use by another listener to be kept")
+	}
+	lis.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket file should be removed on close, stat err: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen("unix://" + path); err == nil {
+		t.Fatal("expected a regular file at the socket path to be rejected")
+	}
+}